@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/RobertoRochaT/rojudger/internal/queue"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/RobertoRochaT/rojudger/internal/worker"
+)
+
+// maxRecoveredJobs limita cuántas submissions de cada estado se reencolan al arrancar
+const maxRecoveredJobs = 10000
+
+// startEmbeddedWorkers arranca los workers dentro del proceso del API (modo
+// all-in-one) y reencola lo que quedó pendiente en la base de datos, porque la
+// cola en memoria empieza vacía en cada arranque.
+func startEmbeddedWorkers(cfg *config.Config, db database.Store, q queue.Queue, exec *executor.Executor, limiter *ratelimit.Limiter) *worker.Pool {
+	pool, err := worker.NewPool(cfg, db, q, exec, limiter)
+	if err != nil {
+		log.Fatalf("Failed to create worker pool: %v", err)
+	}
+	pool.Start()
+	recoverPendingJobs(db, q)
+
+	log.Println("✅ Embedded workers started")
+	return pool
+}
+
+// recoverPendingJobs vuelve a encolar las submissions programadas, en cola o
+// interrumpidas a mitad de ejecución, con la prioridad y la clave de cuota que
+// se guardaron al crearlas.
+func recoverPendingJobs(db database.Store, q queue.Queue) {
+	ctx := context.Background()
+	recovered := 0
+
+	for _, status := range []string{models.StatusScheduled, models.StatusQueued, models.StatusProcessing} {
+		submissions, err := db.GetSubmissionsByStatus(status, maxRecoveredJobs)
+		if err != nil {
+			log.Printf("Failed to load %s submissions: %v", status, err)
+			continue
+		}
+
+		for _, submission := range submissions {
+			job := queue.Job{
+				SubmissionID: submission.ID,
+				Priority:     submission.Priority,
+				LanguageID:   submission.LanguageID,
+				QuotaKey:     submission.QuotaKey,
+			}
+
+			if status == models.StatusScheduled && submission.ScheduledAt != nil {
+				err = q.Schedule(ctx, job, *submission.ScheduledAt)
+			} else {
+				// Las que estaban ejecutándose se cortaron con el proceso anterior
+				if status == models.StatusProcessing {
+					if err := db.UpdateSubmissionStatus(submission.ID, models.StatusQueued); err != nil {
+						log.Printf("Failed to reset submission %s: %v", submission.ID, err)
+						continue
+					}
+				}
+				err = q.Enqueue(ctx, job)
+			}
+			if err != nil {
+				log.Printf("Failed to recover submission %s: %v", submission.ID, err)
+				continue
+			}
+			recovered++
+		}
+	}
+
+	if recovered > 0 {
+		log.Printf("♻️  Recovered %d pending submission(s) into the in-memory queue", recovered)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+
+	"github.com/RobertoRochaT/rojudger/internal/auth"
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// Scopes exigidos por tipo de endpoint
+var (
+	submitScope = requireScope(auth.ScopeSubmit)
+	readScope   = requireScope(auth.ScopeReadOwn, auth.ScopeReadAll)
+	adminScope  = requireScope(auth.ScopeAdmin)
+
+	languagesScope = requireScope(auth.ScopeManageLanguages)
+
+	globalAdminScope = requireGlobalAdmin()
+)
+
+// newAuthenticator crea el autenticador de API keys y JWT
+func newAuthenticator(cfg *config.Config, db database.Store) *auth.Authenticator {
+	if !cfg.AuthEnabled {
+		log.Println("⚠️  Authentication disabled (AUTH_ENABLED=false): every request has admin access")
+	} else if cfg.AdminAPIKey == "" {
+		log.Println("⚠️  ADMIN_API_KEY is not set: only keys already stored in the database are accepted")
+	}
+
+	roleScopes, err := auth.ParseRoleScopes(cfg.JWTRoleScopes)
+	if err != nil {
+		log.Fatalf("Invalid JWT_ROLE_SCOPES: %v", err)
+	}
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+		Secret:        cfg.JWTSecret,
+		JWKSFile:      cfg.JWTJWKSFile,
+		Issuer:        cfg.JWTIssuer,
+		Audience:      cfg.JWTAudience,
+		UserClaim:     cfg.JWTUserClaim,
+		TenantClaim:   cfg.JWTTenantClaim,
+		RolesClaim:    cfg.JWTRolesClaim,
+		PlanClaim:     cfg.JWTPlanClaim,
+		RoleScopes:    roleScopes,
+		DefaultScopes: auth.ParseScopes(cfg.JWTDefaultScopes),
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure JWT authentication: %v", err)
+	}
+	if verifier != nil && cfg.AuthEnabled {
+		log.Println("✓ JWT authentication enabled")
+	}
+
+	return auth.NewAuthenticator(db, cfg.AuthEnabled, cfg.AdminAPIKey, verifier)
+}
+
+// registerAPIKeyRoutes añade los endpoints de administración de API keys
+func registerAPIKeyRoutes(v1 *gin.RouterGroup, db database.Store) {
+	h := handlers.NewAPIKeyHandler(db)
+	v1.POST("/api-keys", adminScope, h.CreateAPIKey)
+	v1.GET("/api-keys", adminScope, h.ListAPIKeys)
+	v1.DELETE("/api-keys/:id", adminScope, h.RevokeAPIKey)
+}
+
+// registerTenantRoutes añade los endpoints de administración de tenants.
+// Crear, listar y modificar es solo para admins globales; un admin de tenant puede ver el suyo.
+func registerTenantRoutes(v1 *gin.RouterGroup, db database.Store) {
+	h := handlers.NewTenantHandler(db)
+	v1.POST("/tenants", globalAdminScope, h.CreateTenant)
+	v1.GET("/tenants", globalAdminScope, h.ListTenants)
+	v1.GET("/tenants/:id", adminScope, h.GetTenant)
+	v1.PUT("/tenants/:id", globalAdminScope, h.UpdateTenant)
+}
+
+// registerUsageRoutes añade la consulta y exportación del consumo medido
+func registerUsageRoutes(v1 *gin.RouterGroup, db database.Store) {
+	h := handlers.NewUsageHandler(db)
+	v1.GET("/usage", adminScope, h.GetUsage)
+}
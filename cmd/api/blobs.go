@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+
+	"github.com/RobertoRochaT/rojudger/internal/blob"
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// registerBlobRoutes sirve los links firmados del backend fs. Con S3 los links
+// apuntan directo al bucket y no hace falta ninguna ruta.
+func registerBlobRoutes(router *gin.Engine, cfg *config.Config) {
+	if cfg.BlobBackend != blob.BackendFS {
+		return
+	}
+
+	store, err := blob.NewFSStore(cfg.BlobFSDir, cfg.BlobPublicURL, cfg.BlobSigningSecret)
+	if err != nil {
+		log.Fatalf("Failed to open blob store: %v", err)
+	}
+	h := handlers.NewBlobHandler(store)
+	router.GET("/blobs/:key", h.DownloadBlob)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log"
+
+	"github.com/RobertoRochaT/rojudger/internal/auth"
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// version se reporta en GET /about de la API compatible con Judge0
+var version = "1.0.0"
+
+// registerJudge0Routes monta la API compatible con Judge0 sobre los handlers nativos.
+// createBatch puede ser nil si el modo no soporta batch.
+func registerJudge0Routes(router *gin.Engine, cfg *config.Config, db database.Store, authenticator *auth.Authenticator, limiter *ratelimit.Limiter, create, createBatch gin.HandlerFunc) {
+	if !cfg.Judge0CompatEnabled {
+		return
+	}
+
+	h := handlers.NewJudge0Handler(db, cfg, version, create, createBatch)
+
+	judge0 := router.Group(cfg.Judge0CompatPrefix)
+	judge0.Use(authMiddleware(authenticator))
+	{
+		judge0.POST("/submissions", submitScope, rateLimitMiddleware(limiter), h.CreateSubmission)
+		judge0.POST("/submissions/batch", submitScope, rateLimitMiddleware(limiter), h.CreateSubmissionsBatch)
+		judge0.GET("/submissions/batch", readScope, h.GetSubmissionsBatch)
+		judge0.GET("/submissions/:token", readScope, h.GetSubmission)
+		judge0.GET("/submissions", readScope, h.GetSubmissions)
+		judge0.GET("/statuses", h.GetStatuses)
+		judge0.GET("/languages", h.GetLanguages)
+		judge0.GET("/languages/:id", h.GetLanguage)
+		judge0.GET("/about", h.GetAbout)
+		judge0.GET("/config_info", h.GetConfigInfo)
+	}
+
+	log.Printf("🔁 Judge0-compatible API mounted at %q", cfg.Judge0CompatPrefix+"/")
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// registerLanguageRoutes añade los endpoints de administración de lenguajes
+func registerLanguageRoutes(v1 *gin.RouterGroup, db database.Store, exec *executor.Executor) {
+	h := handlers.NewLanguageHandler(db, exec)
+	v1.GET("/languages/all", languagesScope, h.ListAllLanguages)
+	v1.POST("/languages", languagesScope, h.CreateLanguage)
+	v1.PUT("/languages/:id", languagesScope, h.UpdateLanguage)
+	v1.POST("/languages/:id/enable", languagesScope, h.EnableLanguage)
+	v1.POST("/languages/:id/disable", languagesScope, h.DisableLanguage)
+	v1.POST("/languages/:id/smoke-test", languagesScope, h.SmokeTestLanguage)
+	v1.GET("/languages/:id/revisions", languagesScope, h.ListLanguageRevisions)
+	v1.POST("/languages/:id/revisions/:revision/restore", languagesScope, h.RestoreLanguageRevision)
+}
@@ -6,12 +6,22 @@ import (
 )
 
 func main() {
+	// Subcomando de migraciones: api migrate <up|down|status>
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Verificar si se debe usar cola o modo directo
 	useQueue := os.Getenv("USE_QUEUE")
 	
-	if useQueue == "true" {
+	// Modo all-in-one: cola en memoria y workers embebidos, sin Redis
+	if useQueue == "all-in-one" || (len(os.Args) > 1 && os.Args[1] == "all-in-one") {
+		log.Println("📦 Running in ALL-IN-ONE mode (in-process queue and workers)")
+		mainWithQueue(true)
+	} else if useQueue == "true" {
 		log.Println("🔄 Running in QUEUE mode (async)")
-		mainWithQueue()
+		mainWithQueue(false)
 	} else {
 		log.Println("⚡ Running in DIRECT mode (sync)")
 		mainDirect()
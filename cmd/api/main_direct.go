@@ -6,10 +6,14 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/RobertoRochaT/rojudger/internal/auth"
 	"github.com/RobertoRochaT/rojudger/internal/config"
 	"github.com/RobertoRochaT/rojudger/internal/database"
 	"github.com/RobertoRochaT/rojudger/internal/executor"
 	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/RobertoRochaT/rojudger/internal/retention"
+	"github.com/RobertoRochaT/rojudger/internal/session"
 	"github.com/gin-gonic/gin"
 )
 
@@ -32,10 +36,8 @@ func mainDirect() {
 	}
 	defer db.Close()
 
-	// Inicializar schema
-	if err := db.InitSchema(); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
-	}
+	// Migrar schema
+	migrateOnStart(cfg, db)
 
 	// Seed de lenguajes
 	if err := db.SeedLanguages(); err != nil {
@@ -52,8 +54,30 @@ func mainDirect() {
 	// Crear handlers
 	h := handlers.NewHandler(db, exec)
 
+	// Sesiones interactivas
+	sessions := newSessionManager(cfg, exec)
+
+	// Autenticación con API keys o JWT
+	authenticator := newAuthenticator(cfg, db)
+
+	// Rate limiting y cuotas por plan (requiere Redis)
+	limiter := newLimiter(cfg)
+	if limiter != nil {
+		defer limiter.Close()
+	}
+
+	// Retención de submissions
+	janitor := newJanitor(cfg, db)
+	defer janitor.Close()
+
 	// Configurar router
-	router := setupRouter(h)
+	router := setupRouter(cfg, h, db, exec, sessions, authenticator, limiter, janitor)
+
+	// Descargas firmadas de payloads grandes
+	registerBlobRoutes(router, cfg)
+
+	// API compatible con Judge0 (sin batch en modo directo)
+	registerJudge0Routes(router, cfg, db, authenticator, limiter, h.CreateSubmission, nil)
 
 	// Servidor con graceful shutdown
 	addr := cfg.ServerHost + ":" + cfg.ServerPort
@@ -61,8 +85,10 @@ func mainDirect() {
 	log.Println("📚 API Documentation:")
 	log.Println("  POST   /api/v1/submissions       - Create submission")
 	log.Println("  GET    /api/v1/submissions/:id   - Get submission")
+	log.Println("  GET    /api/v1/submissions/batch - Get many submissions (?tokens=a,b,c)")
 	log.Println("  GET    /api/v1/submissions       - List submissions by status")
 	log.Println("  GET    /api/v1/languages         - Get supported languages")
+	log.Println("  GET    /api/v1/sessions/ws       - Interactive session (WebSocket)")
 	log.Println("  GET    /health                   - Health check")
 
 	// Capturar señales de sistema para graceful shutdown
@@ -77,9 +103,10 @@ func mainDirect() {
 
 	<-quit
 	log.Println("🛑 Shutting down server...")
+	closeSessions(sessions)
 }
 
-func setupRouter(h *handlers.Handler) *gin.Engine {
+func setupRouter(cfg *config.Config, h *handlers.Handler, db database.Store, exec *executor.Executor, sessions *session.Manager, authenticator *auth.Authenticator, limiter *ratelimit.Limiter, janitor *retention.Janitor) *gin.Engine {
 	router := gin.Default()
 
 	// Middleware CORS
@@ -90,17 +117,29 @@ func setupRouter(h *handlers.Handler) *gin.Engine {
 
 	// API v1
 	v1 := router.Group("/api/v1")
+	v1.Use(authMiddleware(authenticator))
 	{
 		// Submissions
 		submissions := v1.Group("/submissions")
 		{
-			submissions.POST("", h.CreateSubmission)
-			submissions.GET("/:id", h.GetSubmission)
-			submissions.GET("", h.GetSubmissions)
+			submissions.POST("", submitScope, rateLimitMiddleware(limiter), h.CreateSubmission)
+			submissions.GET("/batch", readScope, h.GetSubmissionsBatch)
+			submissions.GET("/:id", readScope, h.GetSubmission)
+			submissions.GET("", readScope, h.GetSubmissions)
 		}
 
 		// Languages
 		v1.GET("/languages", h.GetLanguages)
+		registerLanguageRoutes(v1, db, exec)
+
+		// Sesiones interactivas
+		registerSessionRoutes(v1, db, sessions)
+
+		// API keys
+		registerAPIKeyRoutes(v1, db)
+		registerTenantRoutes(v1, db)
+		registerUsageRoutes(v1, db)
+		registerRetentionRoutes(v1, cfg, db, janitor)
 	}
 
 	return router
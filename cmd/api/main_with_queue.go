@@ -2,16 +2,22 @@ package main
 
 import (
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/RobertoRochaT/rojudger/internal/config"
 	"github.com/RobertoRochaT/rojudger/internal/database"
 	"github.com/RobertoRochaT/rojudger/internal/executor"
 	"github.com/RobertoRochaT/rojudger/internal/handlers"
 	"github.com/RobertoRochaT/rojudger/internal/queue"
+	"github.com/RobertoRochaT/rojudger/internal/worker"
 	"github.com/gin-gonic/gin"
 )
 
-func mainWithQueue() {
+// mainWithQueue arranca el API con cola. En modo all-in-one la cola vive en
+// memoria y los workers corren dentro del mismo proceso, sin Redis.
+func mainWithQueue(allInOne bool) {
 	log.Println("🚀 Starting ROJUDGER API Server with Queue...")
 
 	// Cargar configuración
@@ -24,19 +30,23 @@ func mainWithQueue() {
 	}
 	defer db.Close()
 
-	// Inicializar esquema y datos
-	if err := db.InitSchema(); err != nil {
-		log.Fatalf("Failed to initialize schema: %v", err)
-	}
+	// Migrar esquema e inicializar datos
+	migrateOnStart(cfg, db)
 
 	if err := db.SeedLanguages(); err != nil {
 		log.Fatalf("Failed to seed languages: %v", err)
 	}
 
-	// Conectar a Redis queue
-	q, err := queue.NewQueue(cfg)
-	if err != nil {
-		log.Fatalf("Failed to connect to queue: %v", err)
+	// Conectar a Redis queue (o crear la cola en memoria)
+	var q queue.Queue
+	if allInOne {
+		q = queue.NewMemoryQueue()
+	} else {
+		redisQueue, err := queue.NewRedisQueue(cfg)
+		if err != nil {
+			log.Fatalf("Failed to connect to queue: %v", err)
+		}
+		q = redisQueue
 	}
 	defer q.Close()
 
@@ -50,6 +60,9 @@ func mainWithQueue() {
 	// Crear handler con queue
 	handler := handlers.NewHandlerWithQueue(db, exec, q)
 
+	// Sesiones interactivas (corren en el API, no en los workers)
+	sessions := newSessionManager(cfg, exec)
+
 	// Configurar router Gin
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -60,27 +73,91 @@ func mainWithQueue() {
 	// CORS middleware
 	router.Use(corsMiddleware())
 
+	// Autenticación con API keys o JWT
+	authenticator := newAuthenticator(cfg, db)
+
+	// Rate limiting y cuotas por plan
+	limiter := newLimiter(cfg)
+	if limiter != nil {
+		defer limiter.Close()
+	}
+	rateLimit := rateLimitMiddleware(limiter)
+
+	// Retención de submissions
+	janitor := newJanitor(cfg, db)
+	defer janitor.Close()
+
+	// Workers embebidos (modo all-in-one)
+	var pool *worker.Pool
+	var poolDraining <-chan struct{}
+	if allInOne {
+		pool = startEmbeddedWorkers(cfg, db, q, exec, limiter)
+		poolDraining = pool.Draining()
+	}
+
 	// API v1
 	v1 := router.Group("/api/v1")
+	v1.Use(authMiddleware(authenticator))
 	{
-		v1.POST("/submissions", handler.CreateSubmissionAsync)
-		v1.GET("/submissions/:id", handler.GetSubmission)
-		v1.GET("/submissions", handler.GetSubmissions)
+		v1.POST("/submissions", submitScope, rateLimit, handler.CreateSubmissionAsync)
+		v1.POST("/submissions/batch", submitScope, rateLimit, handler.CreateSubmissionsBatch)
+		v1.GET("/submissions/batch", readScope, handler.GetSubmissionsBatch)
+		v1.GET("/submissions/:id", readScope, handler.GetSubmission)
+		v1.POST("/submissions/:id/cancel", submitScope, handler.CancelSubmission)
+		v1.GET("/submissions/:id/events", readScope, handler.StreamSubmissionEvents)
+		v1.GET("/submissions/:id/ws", readScope, handler.StreamSubmissionWS)
+		v1.GET("/submissions", readScope, handler.GetSubmissions)
 		v1.GET("/languages", handler.GetLanguages)
-		v1.GET("/queue/stats", handler.GetQueueStats)  // ← NUEVO endpoint
+		v1.GET("/queue/stats", globalAdminScope, handler.GetQueueStats)  // ← NUEVO endpoint
+		v1.GET("/workers", globalAdminScope, handler.GetWorkers)
+		v1.POST("/workers/:id/drain", globalAdminScope, handler.DrainWorker)
+
+		registerSessionRoutes(v1, db, sessions)
+		registerAPIKeyRoutes(v1, db)
+		registerTenantRoutes(v1, db)
+		registerUsageRoutes(v1, db)
+		registerRetentionRoutes(v1, cfg, db, janitor)
+		registerLanguageRoutes(v1, db, exec)
 	}
 
 	// Health check
 	router.GET("/health", handler.HealthCheck)
 
+	// Descargas firmadas de payloads grandes
+	registerBlobRoutes(router, cfg)
+
+	// API compatible con Judge0
+	registerJudge0Routes(router, cfg, db, authenticator, limiter, handler.CreateSubmissionAsync, handler.CreateSubmissionsBatch)
+
 	// Iniciar servidor
 	addr := cfg.ServerHost + ":" + cfg.ServerPort
 	log.Printf("✅ Server listening on %s", addr)
 	log.Printf("📊 Queue stats endpoint: http://%s/api/v1/queue/stats", addr)
 	log.Printf("🎯 API endpoint: http://%s/api/v1/submissions", addr)
 
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Capturar señales para cerrar las sesiones abiertas
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		if err := router.Run(addr); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// En modo all-in-one, drenar el worker embebido apaga todo el proceso
+	reason := "admin request"
+	select {
+	case sig := <-quit:
+		reason = "signal " + sig.String()
+	case <-poolDraining:
+	}
+	log.Println("🛑 Shutting down server...")
+	closeSessions(sessions)
+
+	// Terminar los trabajos en curso; una segunda señal fuerza el apagado
+	if pool != nil {
+		pool.Shutdown(reason, quit)
 	}
 }
 
@@ -1,13 +1,31 @@
 package main
 
-import "github.com/gin-gonic/gin"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/auth"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
 
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key, X-User-ID, X-API-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -17,3 +35,162 @@ func corsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// authMiddleware autentica la petición con su API key y guarda el principal en el contexto
+func authMiddleware(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(auth.KeyFromRequest(c))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="rojudger"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		auth.SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// requireScope exige que el principal tenga al menos uno de los scopes
+func requireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.FromContext(c)
+		if principal != nil {
+			for _, scope := range scopes {
+				if principal.HasScope(scope) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":           "Insufficient scope",
+			"required_scopes": scopes,
+		})
+	}
+}
+
+// requireGlobalAdmin exige un admin sin tenant: los endpoints que afectan a
+// todo el servidor no están disponibles para los admins de un tenant
+func requireGlobalAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.FromContext(c)
+		if principal == nil || !principal.IsGlobalAdmin() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Global admin required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitMiddleware limita la creación de submissions: un token del bucket por
+// petición (plan del principal) y, de las cuotas (plan del tenant si tiene), una
+// submission por elemento creado.
+// Si Redis falla la petición se deja pasar.
+func rateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		plan := limiter.Plan(ratelimit.PlanName(c))
+
+		decision, err := limiter.Allow(ctx, ratelimit.BucketKey(c), plan)
+		if err != nil {
+			log.Printf("Rate limiter unavailable: %v", err)
+			c.Next()
+			return
+		}
+		if decision.Limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(decision.Reset).Unix(), 10))
+		}
+		if !decision.Allowed {
+			c.Header("Retry-After", retryAfterSeconds(decision.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+
+		quotaKey := ratelimit.QuotaKey(c)
+		count, err := submissionCount(c)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		now := time.Now()
+
+		quotaPlan := limiter.Plan(ratelimit.QuotaPlanName(c))
+		exceeded, err := limiter.ReserveSubmissions(ctx, quotaKey, quotaPlan, count, now)
+		if err != nil {
+			log.Printf("Rate limiter unavailable: %v", err)
+			c.Next()
+			return
+		}
+		if exceeded != nil {
+			c.Header("Retry-After", retryAfterSeconds(exceeded.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Quota exceeded",
+				"quota": exceeded.Quota,
+				"limit": exceeded.Limit,
+				"used":  exceeded.Used,
+			})
+			return
+		}
+
+		c.Next()
+
+		// Las peticiones que no crearon submissions (errores, reintentos idempotentes)
+		// no consumen cuota
+		bg, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if c.Writer.Status() >= http.StatusBadRequest || c.Writer.Header().Get(handlers.IdempotentReplayHeader) != "" {
+			if err := limiter.ReleaseSubmissions(bg, quotaKey, count, now); err != nil {
+				log.Printf("Failed to release quota: %v", err)
+			}
+		}
+		// Ejecuciones síncronas (modo directo): cargar la CPU consumida
+		if cpu := ratelimit.CPUUsage(c); cpu > 0 {
+			if err := limiter.RecordCPU(bg, quotaKey, cpu, time.Now()); err != nil {
+				log.Printf("Failed to record usage: %v", err)
+			}
+		}
+	}
+}
+
+// maxCountedBodyBytes acota el body que submissionCount lee en memoria
+const maxCountedBodyBytes = 16 << 20
+
+// submissionCount cuenta cuántas submissions crea la petición: el tamaño del
+// arreglo "submissions" en un batch, o 1. Falla si el body supera maxCountedBodyBytes.
+func submissionCount(c *gin.Context) (int, error) {
+	if c.Request.Body == nil {
+		return 1, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCountedBodyBytes))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read request body: %w", err)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var batch struct {
+		Submissions []json.RawMessage `json:"submissions"`
+	}
+	if json.Unmarshal(body, &batch) != nil || len(batch.Submissions) == 0 {
+		return 1, nil
+	}
+	return len(batch.Submissions), nil
+}
+
+// retryAfterSeconds formatea el header Retry-After (segundos, redondeado hacia arriba)
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
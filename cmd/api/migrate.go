@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    revert the last n applied migrations (default 1)
+  status      list migrations and whether they are applied`
+
+// migrateOnStart aplica las migraciones pendientes al arrancar el API. Con
+// DB_AUTO_MIGRATE=false se niega a arrancar si el esquema está desactualizado.
+func migrateOnStart(cfg *config.Config, db *database.DB) {
+	ctx := context.Background()
+
+	if !cfg.DBAutoMigrate {
+		pending, err := db.PendingMigrations(ctx)
+		if err != nil {
+			log.Fatalf("Failed to check migrations: %v", err)
+		}
+		if pending > 0 {
+			log.Fatalf("%d pending migrations and DB_AUTO_MIGRATE=false: run `api migrate up` before starting the API", pending)
+		}
+		return
+	}
+
+	applied, err := db.Migrate(ctx)
+	if err != nil {
+		log.Fatalf("Failed to migrate database schema: %v", err)
+	}
+	log.Printf("Database schema up to date (%d migrations applied)", applied)
+}
+
+// runMigrate implementa el subcomando `migrate`
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	db, err := database.NewDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := db.Migrate(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("✅ %d migrations applied", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				log.Fatalf("Invalid number of migrations to revert: %q", args[1])
+			}
+		}
+		reverted, err := db.MigrateDown(ctx, steps)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("✅ %d migrations reverted", reverted)
+
+	case "status":
+		states, err := db.MigrationStatus(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = "applied " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", state.Version, state.Name, applied)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"log"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+)
+
+// newLimiter crea el rate limiter (nil si RATE_LIMIT_ENABLED=false)
+func newLimiter(cfg *config.Config) *ratelimit.Limiter {
+	if !cfg.RateLimitEnabled {
+		return nil
+	}
+
+	limiter, err := ratelimit.NewLimiter(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	return limiter
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/RobertoRochaT/rojudger/internal/retention"
+	"github.com/gin-gonic/gin"
+)
+
+// newJanitor crea el janitor de retención y lo arranca si RETENTION_ENABLED=true.
+// Aunque esté deshabilitado, los admins pueden dispararlo a mano.
+func newJanitor(cfg *config.Config, db database.Store) *retention.Janitor {
+	janitor, err := retention.NewJanitor(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to load retention policies: %v", err)
+	}
+	janitor.Start()
+	return janitor
+}
+
+// registerRetentionRoutes añade los endpoints de administración de la retención
+func registerRetentionRoutes(v1 *gin.RouterGroup, cfg *config.Config, db database.Store, janitor *retention.Janitor) {
+	h := handlers.NewRetentionHandler(cfg, db, janitor)
+	v1.POST("/retention/runs", globalAdminScope, h.TriggerRetentionRun)
+	v1.GET("/retention/runs", globalAdminScope, h.ListRetentionRuns)
+	v1.GET("/retention/runs/:id", globalAdminScope, h.GetRetentionRun)
+	v1.GET("/retention/policies", globalAdminScope, h.GetRetentionPolicies)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/handlers"
+	"github.com/RobertoRochaT/rojudger/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+// newSessionManager crea el administrador de sesiones interactivas (nil si están deshabilitadas)
+func newSessionManager(cfg *config.Config, exec *executor.Executor) *session.Manager {
+	if !cfg.SessionsEnabled {
+		return nil
+	}
+
+	manager := session.NewManager(exec, session.Config{
+		MaxDuration: cfg.SessionMaxDuration,
+		IdleTimeout: cfg.SessionIdleTimeout,
+		MaxPerUser:  cfg.SessionMaxPerUser,
+		MaxTotal:    cfg.SessionMaxTotal,
+	})
+
+	// Eliminar contenedores que quedaron de un proceso anterior
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := manager.Cleanup(ctx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	return manager
+}
+
+// registerSessionRoutes añade los endpoints de sesiones interactivas
+func registerSessionRoutes(v1 *gin.RouterGroup, db database.Store, manager *session.Manager) {
+	if manager == nil {
+		return
+	}
+
+	h := handlers.NewSessionHandler(db, manager)
+	v1.GET("/sessions/ws", submitScope, h.OpenSession)
+	v1.GET("/sessions", globalAdminScope, h.ListSessions)
+	v1.DELETE("/sessions/:id", globalAdminScope, h.TerminateSession)
+}
+
+// closeSessions cierra las sesiones abiertas al apagar el servidor
+func closeSessions(manager *session.Manager) {
+	if manager == nil {
+		return
+	}
+	manager.CloseAll(session.ReasonShutdown)
+}
@@ -5,15 +5,14 @@ import (
 	"log"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
-	"time"
 
 	"github.com/RobertoRochaT/rojudger/internal/config"
 	"github.com/RobertoRochaT/rojudger/internal/database"
 	"github.com/RobertoRochaT/rojudger/internal/executor"
 	"github.com/RobertoRochaT/rojudger/internal/queue"
-	"github.com/RobertoRochaT/rojudger/internal/webhook"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/RobertoRochaT/rojudger/internal/worker"
 )
 
 func main() {
@@ -28,9 +27,10 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
+	ensureSchema(cfg, db)
 
 	// Conectar a Redis queue
-	q, err := queue.NewQueue(cfg)
+	q, err := queue.NewRedisQueue(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to queue: %v", err)
 	}
@@ -43,155 +43,62 @@ func main() {
 	}
 	defer exec.Close()
 
-	// Crear webhook service
-	hmacSecret := os.Getenv("WEBHOOK_SECRET")
-	if hmacSecret == "" {
-		log.Println("⚠️  WEBHOOK_SECRET not set. Webhooks will be sent without HMAC signatures.")
-	}
-	webhookService := webhook.NewWebhookService(30*time.Second, 3, hmacSecret)
-
-	// Número de workers concurrentes
-	numWorkers := cfg.ExecutorMaxConcurrent
-	if numWorkers == 0 {
-		numWorkers = 5
+	// Contabilidad de CPU para las cuotas (el límite se verifica en el API)
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		limiter, err = ratelimit.NewLimiter(cfg)
+		if err != nil {
+			log.Fatalf("Failed to create rate limiter: %v", err)
+		}
+		defer limiter.Close()
 	}
 
-	log.Printf("Starting %d concurrent workers", numWorkers)
-
 	// Canal para señales de sistema
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Context para cancelación
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// WaitGroup para esperar a que todos los workers terminen
-	var wg sync.WaitGroup
-
-	// Iniciar workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			runWorker(ctx, workerID, db, q, exec, webhookService)
-		}(i + 1)
+	pool, err := worker.NewPool(cfg, db, q, exec, limiter)
+	if err != nil {
+		log.Fatalf("Failed to create worker pool: %v", err)
 	}
+	pool.Start()
 
 	log.Println("✅ Workers started. Press Ctrl+C to stop.")
 
-	// Esperar señal de terminación
-	<-sigChan
-	log.Println("⚠️  Shutdown signal received. Stopping workers...")
-
-	// Cancelar context para detener workers
-	cancel()
+	// Esperar señal de terminación o pedido de drain desde la API
+	reason := "admin request"
+	select {
+	case sig := <-sigChan:
+		reason = "signal " + sig.String()
+	case <-pool.Draining():
+	}
 
-	// Esperar a que todos los workers terminen
-	wg.Wait()
+	// Una segunda señal fuerza el apagado inmediato
+	pool.Shutdown(reason, sigChan)
 
 	log.Println("✅ All workers stopped. Goodbye!")
 }
 
-func runWorker(ctx context.Context, workerID int, db *database.DB, q *queue.Queue, exec *executor.Executor, webhookService *webhook.WebhookService) {
-	log.Printf("Worker #%d started", workerID)
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("Worker #%d stopping...", workerID)
-			return
-		default:
-			// Intentar obtener un trabajo (esperar hasta 5 segundos)
-			job, err := q.Dequeue(ctx, 5*time.Second)
-			if err != nil {
-				log.Printf("Worker #%d: Error dequeuing job: %v", workerID, err)
-				time.Sleep(1 * time.Second)
-				continue
-			}
-
-			// Si no hay trabajos, continuar esperando
-			if job == nil {
-				continue
-			}
-
-			// Procesar el trabajo
-			log.Printf("Worker #%d: Processing job %s", workerID, job.SubmissionID)
-
-			if err := processSubmission(ctx, workerID, job.SubmissionID, db, exec, q, webhookService); err != nil {
-				log.Printf("Worker #%d: Error processing job %s: %v", workerID, job.SubmissionID, err)
-				q.MarkFailed(ctx, job.SubmissionID, false)
-			} else {
-				log.Printf("Worker #%d: Job %s completed successfully", workerID, job.SubmissionID)
-				q.MarkComplete(ctx, job.SubmissionID)
-			}
-		}
-	}
-}
-
-func processSubmission(ctx context.Context, workerID int, submissionID string, db *database.DB, exec *executor.Executor, q *queue.Queue, webhookService *webhook.WebhookService) error {
-	// 1. Obtener submission de la base de datos
-	submission, err := db.GetSubmission(submissionID)
-	if err != nil {
-		return err
-	}
+// ensureSchema garantiza que el esquema esté al día antes de procesar jobs: con
+// DB_AUTO_MIGRATE aplica las migraciones pendientes igual que el API (el lock
+// de migraciones los serializa); sin él, se niega a arrancar con un esquema viejo.
+func ensureSchema(cfg *config.Config, db *database.DB) {
+	ctx := context.Background()
 
-	// 2. Actualizar estado a "processing"
-	submission.Status = "processing"
-	if err := db.UpdateSubmission(submission); err != nil {
-		return err
+	if cfg.DBAutoMigrate {
+		applied, err := db.Migrate(ctx)
+		if err != nil {
+			log.Fatalf("Failed to migrate database schema: %v", err)
+		}
+		log.Printf("Database schema up to date (%d migrations applied)", applied)
+		return
 	}
 
-	// 3. Obtener información del lenguaje
-	language, err := db.GetLanguage(submission.LanguageID)
+	pending, err := db.PendingMigrations(ctx)
 	if err != nil {
-		return err
+		log.Fatalf("Failed to check migrations: %v", err)
 	}
-
-	// 4. Ejecutar el código
-	log.Printf("Worker #%d: Executing code for submission %s (language: %s)",
-		workerID, submissionID, language.DisplayName)
-
-	result := exec.Execute(ctx, submission, language)
-
-	// 5. Actualizar submission con los resultados
-	now := time.Now()
-	submission.FinishedAt = &now
-	submission.Stdout = result.Stdout
-	submission.Stderr = result.Stderr
-	submission.ExitCode = result.ExitCode
-	submission.Time = result.Time
-	submission.Memory = result.Memory
-	submission.CompileOut = result.CompileOut
-
-	if result.TimedOut {
-		submission.Status = "timeout"
-		submission.Message = "Execution timed out"
-	} else if result.Error != "" {
-		submission.Status = "error"
-		submission.Message = result.Error
-	} else {
-		submission.Status = "completed"
+	if pending > 0 {
+		log.Fatalf("%d pending migrations and DB_AUTO_MIGRATE=false: run `api migrate up` before starting the worker", pending)
 	}
-
-	// 6. Guardar en base de datos
-	if err := db.UpdateSubmission(submission); err != nil {
-		return err
-	}
-
-	// 7. Enviar webhook si está configurado
-	if submission.WebhookURL != "" {
-		log.Printf("Worker #%d: Sending webhook for submission %s to %s",
-			workerID, submissionID, submission.WebhookURL)
-
-		// Enviar de forma asíncrona con logging
-		webhookService.SendAsync(submission.WebhookURL, submission, func(submissionID, webhookURL string, attempt, statusCode int, responseBody, errorMsg string) {
-			// Log en base de datos
-			if err := db.LogWebhookAttempt(submissionID, webhookURL, attempt, statusCode, responseBody, errorMsg); err != nil {
-				log.Printf("Worker #%d: Failed to log webhook attempt: %v", workerID, err)
-			}
-		})
-	}
-
-	return nil
 }
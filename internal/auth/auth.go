@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Scopes de las API keys
+const (
+	ScopeSubmit          = "submit"           // crear y cancelar submissions, abrir sesiones
+	ScopeReadOwn         = "read-own"         // leer las submissions propias
+	ScopeReadAll         = "read-all"         // leer todas las submissions
+	ScopeAdmin           = "admin"            // todo, incluyendo workers, sesiones y API keys
+	ScopeManageLanguages = "manage-languages" // administrar lenguajes
+)
+
+// AllScopes son los scopes válidos
+var AllScopes = []string{ScopeSubmit, ScopeReadOwn, ScopeReadAll, ScopeAdmin, ScopeManageLanguages}
+
+// keyPrefix identifica las API keys de ROJUDGER
+const keyPrefix = "rjk_"
+
+// principalKey es la clave del gin.Context donde se guarda el Principal
+const principalKey = "rojudger.principal"
+
+var (
+	// ErrMissingCredentials indica que la petición no trae credenciales
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials indica una API key inexistente o revocada
+	ErrInvalidCredentials = errors.New("invalid or revoked API key")
+)
+
+// Principal es quien hace la petición
+type Principal struct {
+	KeyID    string   // API key usada ("" con JWT o si la autenticación está deshabilitada)
+	Name     string   // nombre descriptivo de la key o del usuario
+	Scopes   []string // permisos concedidos
+	UserID   string   // usuario final (solo con JWT)
+	TenantID string   // tenant de la key o del usuario ("" = global)
+	Roles    []string // roles del token (solo con JWT)
+	Plan     string   // plan de rate limiting, y de cuotas si no hay tenant ("" = default)
+	// TenantPlan es el plan de cuotas del tenant: sus contadores son compartidos
+	// por todas sus keys y usuarios, así que el límite también
+	TenantPlan string
+}
+
+// HasScope indica si el principal tiene el scope (admin los incluye todos)
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner es el identificador que se guarda como dueño de las submissions
+func (p *Principal) Owner() string {
+	return p.KeyID
+}
+
+// Subject identifica al principal para separar recursos entre clientes
+// (idempotency keys, sesiones): la API key o el usuario del token
+func (p *Principal) Subject() string {
+	if p.KeyID != "" {
+		return p.KeyID
+	}
+	if p.UserID != "" {
+		if p.TenantID != "" {
+			return "jwt:" + p.TenantID + "/" + p.UserID
+		}
+		return "jwt:" + p.UserID
+	}
+	return ""
+}
+
+// IsGlobalAdmin indica si el principal administra toda la instancia y no solo un tenant
+func (p *Principal) IsGlobalAdmin() bool {
+	return p.TenantID == "" && p.HasScope(ScopeAdmin)
+}
+
+// CanReadAll indica si el principal puede leer submissions de otros (de su tenant, si tiene)
+func (p *Principal) CanReadAll() bool {
+	return p.HasScope(ScopeReadAll)
+}
+
+// Authenticator valida las credenciales de las peticiones
+type Authenticator struct {
+	db       database.Store
+	enabled  bool
+	adminKey string       // key de arranque con scope admin (ADMIN_API_KEY)
+	jwt      *JWTVerifier // nil si no se aceptan JWTs
+}
+
+// NewAuthenticator crea un autenticador. Si enabled es false todas las peticiones
+// se tratan como admin (útil en desarrollo). jwt puede ser nil.
+func NewAuthenticator(db database.Store, enabled bool, adminKey string, jwt *JWTVerifier) *Authenticator {
+	return &Authenticator{
+		db:       db,
+		enabled:  enabled,
+		adminKey: adminKey,
+		jwt:      jwt,
+	}
+}
+
+// Enabled indica si se exigen credenciales
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// Authenticate valida una API key o un JWT y retorna su principal
+func (a *Authenticator) Authenticate(key string) (*Principal, error) {
+	if !a.enabled {
+		return &Principal{Name: "anonymous", Scopes: []string{ScopeAdmin}}, nil
+	}
+	if key == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	if a.adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.adminKey)) == 1 {
+		return &Principal{KeyID: "admin", Name: "bootstrap admin", Scopes: []string{ScopeAdmin}}, nil
+	}
+
+	if a.jwt != nil && LooksLikeJWT(key) {
+		principal, err := a.jwt.Verify(key, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		// El tenant del token debe estar registrado
+		if principal.TenantID != "" {
+			tenant, err := a.db.GetTenant(principal.TenantID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unknown tenant %s", ErrInvalidToken, principal.TenantID)
+			}
+			principal.TenantPlan = tenant.Plan
+		}
+		return principal, nil
+	}
+
+	apiKey, err := a.db.GetAPIKeyByHash(HashKey(key))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	a.db.TouchAPIKey(apiKey.ID)
+
+	principal := &Principal{
+		KeyID:    apiKey.ID,
+		Name:     apiKey.Name,
+		Scopes:   apiKey.Scopes,
+		Plan:     apiKey.Plan,
+		TenantID: apiKey.TenantID,
+	}
+	if principal.TenantID != "" {
+		tenant, err := a.db.GetTenant(principal.TenantID)
+		if err != nil {
+			return nil, ErrInvalidCredentials
+		}
+		principal.TenantPlan = tenant.Plan
+	}
+	return principal, nil
+}
+
+// GenerateKey crea una API key nueva. Retorna la key en claro (se muestra una sola vez)
+// y el registro a guardar, que solo contiene su hash.
+func GenerateKey(id, name string, scopes []string) (string, *models.APIKey, error) {
+	if err := ValidateScopes(scopes); err != nil {
+		return "", nil, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := keyPrefix + hex.EncodeToString(secret)
+
+	return key, &models.APIKey{
+		ID:     id,
+		Name:   name,
+		Prefix: key[:len(keyPrefix)+8],
+		Hash:   HashKey(key),
+		Scopes: scopes,
+	}, nil
+}
+
+// HashKey calcula el hash con el que se guarda una key.
+// Las keys son aleatorias y largas, por lo que SHA-256 es suficiente.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateScopes verifica que todos los scopes existan
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range AllScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	return nil
+}
+
+// KeyFromRequest extrae la API key (o el JWT) de Authorization: Bearer, X-API-Key o
+// el query param api_key (para EventSource y WebSocket desde el navegador)
+func KeyFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	return c.Query("api_key")
+}
+
+// SetPrincipal guarda el principal autenticado en el contexto de la petición
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+}
+
+// FromContext retorna el principal de la petición (nil si no pasó por el middleware)
+func FromContext(c *gin.Context) *Principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	p, _ := value.(*Principal)
+	return p
+}
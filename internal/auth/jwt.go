@@ -0,0 +1,361 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken indica un JWT mal formado, con firma inválida o expirado
+var ErrInvalidToken = errors.New("invalid token")
+
+// clockSkew es la tolerancia al validar exp/nbf entre relojes de distintos servidores
+const clockSkew = time.Minute
+
+// JWTConfig configura la validación de tokens emitidos por un proveedor externo (LMS, OIDC)
+type JWTConfig struct {
+	Secret        string              // secreto compartido para HS256
+	JWKSFile      string              // archivo JWKS local con las llaves públicas RS256
+	Issuer        string              // iss esperado ("" = no validar)
+	Audience      string              // aud esperado ("" = no validar)
+	UserClaim     string              // claim con el ID del usuario (p.ej. "sub")
+	TenantClaim   string              // claim con el tenant
+	RolesClaim    string              // claim con los roles (admite rutas como "realm_access.roles")
+	PlanClaim     string              // claim con el plan de rate limiting y cuotas
+	RoleScopes    map[string][]string // scopes que otorga cada rol
+	DefaultScopes []string            // scopes si ningún rol del token está en RoleScopes
+}
+
+// JWTVerifier valida JWTs y los convierte en principals
+type JWTVerifier struct {
+	config  JWTConfig
+	rsaKeys map[string]*rsa.PublicKey // por kid
+}
+
+// NewJWTVerifier crea un verificador. Retorna nil si no hay secreto ni JWKS configurado.
+func NewJWTVerifier(config JWTConfig) (*JWTVerifier, error) {
+	if config.Secret == "" && config.JWKSFile == "" {
+		return nil, nil
+	}
+	if config.UserClaim == "" {
+		config.UserClaim = "sub"
+	}
+	if len(config.DefaultScopes) > 0 {
+		if err := ValidateScopes(config.DefaultScopes); err != nil {
+			return nil, fmt.Errorf("invalid default scopes: %w", err)
+		}
+	}
+	for role, scopes := range config.RoleScopes {
+		if err := ValidateScopes(scopes); err != nil {
+			return nil, fmt.Errorf("invalid scopes for role %s: %w", role, err)
+		}
+	}
+
+	v := &JWTVerifier{config: config}
+	if config.JWKSFile != "" {
+		keys, err := loadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.rsaKeys = keys
+	}
+	return v, nil
+}
+
+// LooksLikeJWT indica si la credencial tiene forma de JWT (header.payload.firma)
+func LooksLikeJWT(token string) bool {
+	return !strings.HasPrefix(token, keyPrefix) && strings.Count(token, ".") == 2
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify valida firma y claims estándar del token y retorna su principal
+func (v *JWTVerifier) Verify(token string, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.validateClaims(claims, now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return v.principal(claims)
+}
+
+// verifySignature valida la firma según el algoritmo del header.
+// Solo se aceptan los algoritmos que tienen llave configurada (nunca "none").
+func (v *JWTVerifier) verifySignature(header jwtHeader, signed string, signature []byte) error {
+	switch header.Alg {
+	case "HS256":
+		if v.config.Secret == "" {
+			return fmt.Errorf("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, []byte(v.config.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("signature mismatch")
+		}
+		return nil
+
+	case "RS256":
+		key, err := v.rsaKey(header.Kid)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("signature mismatch")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+}
+
+// rsaKey busca la llave por kid; si el token no trae kid y hay una sola llave, usa esa
+func (v *JWTVerifier) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if len(v.rsaKeys) == 0 {
+		return nil, fmt.Errorf("RS256 tokens are not accepted")
+	}
+	if key, ok := v.rsaKeys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(v.rsaKeys) == 1 {
+		for _, key := range v.rsaKeys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// validateClaims verifica exp, nbf, iss y aud
+func (v *JWTVerifier) validateClaims(claims map[string]interface{}, now time.Time) error {
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(time.Unix(exp, 0).Add(clockSkew)) {
+		return fmt.Errorf("token expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(clockSkew).Before(time.Unix(nbf, 0)) {
+		return fmt.Errorf("token not valid yet")
+	}
+
+	if v.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+			return fmt.Errorf("unexpected issuer")
+		}
+	}
+	if v.config.Audience != "" && !containsString(stringsClaim(claims["aud"]), v.config.Audience) {
+		return fmt.Errorf("unexpected audience")
+	}
+	return nil
+}
+
+// principal mapea los claims configurados a un Principal
+func (v *JWTVerifier) principal(claims map[string]interface{}) (*Principal, error) {
+	userID := claimString(lookupClaim(claims, v.config.UserClaim))
+	if userID == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.config.UserClaim)
+	}
+
+	p := &Principal{
+		Name:   userID,
+		UserID: userID,
+	}
+	if v.config.TenantClaim != "" {
+		p.TenantID = claimString(lookupClaim(claims, v.config.TenantClaim))
+	}
+	if v.config.RolesClaim != "" {
+		p.Roles = stringsClaim(lookupClaim(claims, v.config.RolesClaim))
+	}
+	if v.config.PlanClaim != "" {
+		p.Plan = claimString(lookupClaim(claims, v.config.PlanClaim))
+	}
+
+	for _, role := range p.Roles {
+		for _, scope := range v.config.RoleScopes[role] {
+			if !containsString(p.Scopes, scope) {
+				p.Scopes = append(p.Scopes, scope)
+			}
+		}
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = v.config.DefaultScopes
+	}
+	return p, nil
+}
+
+// ParseRoleScopes lee el mapeo de roles a scopes con formato
+// "admin=admin;teacher=submit,read-all;student=submit,read-own"
+func ParseRoleScopes(value string) (map[string][]string, error) {
+	mapping := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, scopes, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(role) == "" {
+			return nil, fmt.Errorf("invalid role mapping %q", entry)
+		}
+		mapping[strings.TrimSpace(role)] = ParseScopes(scopes)
+	}
+	return mapping, nil
+}
+
+// ParseScopes separa una lista de scopes por comas
+func ParseScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// jwks es el formato estándar de un JSON Web Key Set
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS lee las llaves RSA de un archivo JWKS
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no RSA signing keys", path)
+	}
+	return keys, nil
+}
+
+// decodeSegment decodifica una parte base64url del token como JSON
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// lookupClaim busca un claim, permitiendo rutas anidadas separadas por puntos
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	if value, ok := claims[path]; ok {
+		return value
+	}
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+	return current
+}
+
+// numericClaim lee un claim de tipo NumericDate
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	value, ok := claims[name].(float64)
+	return int64(value), ok
+}
+
+// claimString convierte un claim escalar a string
+func claimString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
+
+// stringsClaim lee un claim que puede ser un arreglo o un string separado por espacios/comas
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
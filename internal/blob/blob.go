@@ -0,0 +1,89 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+)
+
+// Backends soportados (BLOB_BACKEND)
+const (
+	BackendFS = "fs"
+	BackendS3 = "s3"
+)
+
+// Cómo se entregan las salidas guardadas fuera de línea (BLOB_DELIVERY)
+const (
+	DeliveryInline = "inline" // se leen del blob store al consultar la submission
+	DeliveryLink   = "link"   // se retornan URLs firmadas
+)
+
+// ErrNotFound indica que no existe un blob con esa clave
+var ErrNotFound = errors.New("blob not found")
+
+// Store guarda payloads grandes (código, entradas y salidas) fuera de la base de
+// datos. Las claves son el SHA-256 del contenido, así que el mismo contenido se
+// guarda una sola vez.
+type Store interface {
+	// Put guarda data bajo key. Guardar dos veces la misma clave no es un error.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get lee un blob; retorna ErrNotFound si no existe
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete borra un blob; no falla si no existe
+	Delete(ctx context.Context, key string) error
+	// SignedURL retorna una URL de descarga válida durante ttl
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New crea el backend configurado en BLOB_BACKEND (nil si está deshabilitado)
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.BlobBackend {
+	case "":
+		return nil, nil
+	case BackendFS:
+		if cfg.BlobDelivery == DeliveryLink && cfg.BlobSigningSecret == "" {
+			return nil, fmt.Errorf("BLOB_SIGNING_SECRET is required for BLOB_DELIVERY=link with the fs backend")
+		}
+		return NewFSStore(cfg.BlobFSDir, cfg.BlobPublicURL, cfg.BlobSigningSecret)
+	case BackendS3:
+		return NewS3Store(S3Config{
+			Endpoint:  cfg.BlobS3Endpoint,
+			Region:    cfg.BlobS3Region,
+			Bucket:    cfg.BlobS3Bucket,
+			AccessKey: cfg.BlobS3AccessKey,
+			SecretKey: cfg.BlobS3SecretKey,
+			PathStyle: cfg.BlobS3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported BLOB_BACKEND %q (use %s or %s)", cfg.BlobBackend, BackendFS, BackendS3)
+	}
+}
+
+// Key retorna la clave de un contenido (SHA-256 en hexadecimal)
+func Key(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidKey indica si key tiene la forma de una clave generada por Key
+func ValidKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	for _, r := range key {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// objectPath reparte las claves en subdirectorios por sus dos primeros caracteres
+func objectPath(key string) string {
+	return key[:2] + "/" + key
+}
@@ -0,0 +1,137 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FSStore guarda los blobs en un directorio local. Las URLs firmadas apuntan
+// a GET /blobs/:key del API, que verifica la firma con Verify.
+type FSStore struct {
+	dir       string
+	publicURL string
+	secret    []byte
+}
+
+// NewFSStore crea (si hace falta) el directorio de blobs
+func NewFSStore(dir, publicURL, signingSecret string) (*FSStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("BLOB_FS_DIR is required for the fs blob backend")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob dir: %w", err)
+	}
+	return &FSStore{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/"), secret: []byte(signingSecret)}, nil
+}
+
+func (s *FSStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(objectPath(key))), nil
+}
+
+// Put escribe el blob en un archivo temporal y lo renombra, así un lector nunca
+// ve un blob a medio escribir. Si ya existe no lo vuelve a escribir.
+func (s *FSStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create blob dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+// Get lee un blob del disco
+func (s *FSStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+// Delete borra un blob del disco
+func (s *FSStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// SignedURL retorna <BLOB_PUBLIC_URL>/blobs/<key>?expires=<unix>&signature=<hmac>
+func (s *FSStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if len(s.secret) == 0 {
+		return "", fmt.Errorf("BLOB_SIGNING_SECRET is required for fs blob links")
+	}
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	expires := time.Now().Add(ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(key, expires))
+	return s.publicURL + "/blobs/" + key + "?" + query.Encode(), nil
+}
+
+// Verify comprueba la firma y la vigencia de un link generado por SignedURL
+func (s *FSStore) Verify(key, expires, signature string) bool {
+	if len(s.secret) == 0 || !ValidKey(key) {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.sign(key, unix)))
+}
+
+func (s *FSStore) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -17,12 +18,15 @@ type Config struct {
 	Environment string
 
 	// Database configuration
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
+	DBDriver      string // postgres o sqlite
+	SQLitePath    string // archivo de la base SQLite (":memory:" = en memoria)
+	DBHost        string
+	DBPort        string
+	DBUser        string
+	DBPassword    string
+	DBName        string
+	DBSSLMode     string
+	DBAutoMigrate bool // aplicar migraciones pendientes al arrancar el API y el worker
 
 	// Redis configuration
 	RedisHost     string
@@ -39,6 +43,77 @@ type Config struct {
 	// Docker configuration
 	DockerHost string
 	DockerAPI  string
+
+	// Worker configuration
+	WorkerID                string        // vacío = hostname-pid
+	WorkerHeartbeatInterval time.Duration // cada cuánto se reporta vivo en Redis
+	WorkerLanguages         []int         // lenguajes que ejecuta; vacío = detectar por imágenes locales
+	WorkerGeneralPool       bool          // también consume trabajos del pool general
+	WorkerDrainTimeout      time.Duration // tiempo máximo para terminar trabajos al apagarse
+
+	// Idempotency configuration
+	IdempotencyTTL time.Duration // tiempo que se recuerda un Idempotency-Key
+
+	// Interactive session configuration
+	SessionsEnabled    bool
+	SessionMaxDuration time.Duration // duración máxima de una sesión
+	SessionIdleTimeout time.Duration // se cierra tras este tiempo sin actividad
+	SessionMaxPerUser  int           // sesiones simultáneas por usuario
+	SessionMaxTotal    int           // sesiones simultáneas por servidor
+
+	// Authentication configuration
+	AuthEnabled bool   // exigir API key en /api/v1 y en la API compatible con Judge0
+	AdminAPIKey string // key de arranque con scope admin, para crear las demás
+
+	// JWT (alternativa a las API keys; se habilita con un secreto HS256 o un JWKS)
+	JWTSecret        string // secreto compartido HS256
+	JWTJWKSFile      string // archivo JWKS con las llaves públicas RS256
+	JWTIssuer        string // iss esperado ("" = no validar)
+	JWTAudience      string // aud esperado ("" = no validar)
+	JWTUserClaim     string // claim con el ID del usuario
+	JWTTenantClaim   string // claim con el tenant
+	JWTRolesClaim    string // claim con los roles
+	JWTPlanClaim     string // claim con el plan de rate limiting y cuotas
+	JWTRoleScopes    string // mapeo rol=scopes, p.ej. "admin=admin;teacher=submit,read-all"
+	JWTDefaultScopes string // scopes si ningún rol del token está mapeado
+
+	// Rate limiting y cuotas (plan default; otros planes en RateLimitPlansFile)
+	RateLimitEnabled           bool
+	RateLimitPlansFile         string  // JSON con la lista de planes
+	RateLimitRequestsPerMinute float64 // recarga del token bucket por API key/IP
+	RateLimitBurst             int     // capacidad del token bucket
+	QuotaDailySubmissions      int     // 0 = sin límite
+	QuotaMonthlySubmissions    int
+	QuotaDailyCPUSeconds       float64
+	QuotaMonthlyCPUSeconds     float64
+
+	// Retención de submissions (regla general; otras reglas en RetentionPoliciesFile)
+	RetentionEnabled      bool          // correr el janitor periódicamente
+	RetentionInterval     time.Duration // cada cuánto corre el janitor
+	RetentionBatchSize    int           // filas por lote
+	RetentionPayloadDays  int           // días hasta borrar código, stdin y salidas (0 = nunca)
+	RetentionMetadataDays int           // días hasta borrar la submission completa (0 = nunca)
+	RetentionPoliciesFile string        // JSON con reglas por tenant/estado
+	RetentionArchiveDir   string        // archivar lo purgado en JSONL.gz ("" = sin archivo)
+
+	// Almacenamiento de payloads grandes fuera de la base de datos
+	BlobBackend       string        // "" (deshabilitado), fs o s3
+	BlobThreshold     int           // bytes a partir de los cuales un texto se guarda fuera de línea
+	BlobDelivery      string        // inline (se rehidratan) o link (URLs firmadas para las salidas)
+	BlobLinkTTL       time.Duration // validez de las URLs firmadas
+	BlobFSDir         string        // directorio del backend fs
+	BlobPublicURL     string        // URL base del API para los links del backend fs ("" = rutas relativas)
+	BlobSigningSecret string        // secreto HMAC de los links del backend fs
+	BlobS3Endpoint    string        // p.ej. https://s3.amazonaws.com o http://localhost:9000 (MinIO)
+	BlobS3Region      string
+	BlobS3Bucket      string
+	BlobS3AccessKey   string
+	BlobS3SecretKey   string
+	BlobS3PathStyle   bool // endpoint/bucket/key en lugar de bucket.endpoint/key
+
+	// Judge0 compatibility configuration
+	Judge0CompatEnabled bool
+	Judge0CompatPrefix  string // ruta base de la API compatible ("" = raíz, como Judge0)
 }
 
 var AppConfig *Config
@@ -57,12 +132,15 @@ func Load() *Config {
 		Environment: getEnv("ENVIRONMENT", "development"),
 
 		// Database
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "rojudger"),
-		DBPassword: getEnv("DB_PASSWORD", "rojudger"),
-		DBName:     getEnv("DB_NAME", "rojudger"),
-		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+		DBDriver:      getEnv("DB_DRIVER", "postgres"),
+		SQLitePath:    getEnv("SQLITE_PATH", "rojudger.db"),
+		DBHost:        getEnv("DB_HOST", "localhost"),
+		DBPort:        getEnv("DB_PORT", "5432"),
+		DBUser:        getEnv("DB_USER", "rojudger"),
+		DBPassword:    getEnv("DB_PASSWORD", "rojudger"),
+		DBName:        getEnv("DB_NAME", "rojudger"),
+		DBSSLMode:     getEnv("DB_SSLMODE", "disable"),
+		DBAutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
 
 		// Redis
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
@@ -79,6 +157,77 @@ func Load() *Config {
 		// Docker
 		DockerHost: getEnv("DOCKER_HOST", "unix:///var/run/docker.sock"),
 		DockerAPI:  getEnv("DOCKER_API_VERSION", "1.42"),
+
+		// Worker
+		WorkerID:                getEnv("WORKER_ID", ""),
+		WorkerHeartbeatInterval: getEnvAsDuration("WORKER_HEARTBEAT_INTERVAL", 5*time.Second),
+		WorkerLanguages:         getEnvAsIntList("WORKER_LANGUAGES"),
+		WorkerGeneralPool:       getEnvAsBool("WORKER_GENERAL_POOL", true),
+		WorkerDrainTimeout:      getEnvAsDuration("WORKER_DRAIN_TIMEOUT", 60*time.Second),
+
+		// Idempotency
+		IdempotencyTTL: getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		// Interactive sessions
+		SessionsEnabled:    getEnvAsBool("SESSIONS_ENABLED", false),
+		SessionMaxDuration: getEnvAsDuration("SESSION_MAX_DURATION", 15*time.Minute),
+		SessionIdleTimeout: getEnvAsDuration("SESSION_IDLE_TIMEOUT", 3*time.Minute),
+		SessionMaxPerUser:  getEnvAsInt("SESSION_MAX_PER_USER", 2),
+		SessionMaxTotal:    getEnvAsInt("SESSION_MAX_TOTAL", 20),
+
+		// Auth
+		AuthEnabled: getEnvAsBool("AUTH_ENABLED", false),
+		AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
+
+		// JWT
+		JWTSecret:        getEnv("JWT_HS256_SECRET", ""),
+		JWTJWKSFile:      getEnv("JWT_JWKS_FILE", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
+		JWTUserClaim:     getEnv("JWT_USER_CLAIM", "sub"),
+		JWTTenantClaim:   getEnv("JWT_TENANT_CLAIM", "tenant_id"),
+		JWTRolesClaim:    getEnv("JWT_ROLES_CLAIM", "roles"),
+		JWTPlanClaim:     getEnv("JWT_PLAN_CLAIM", "plan"),
+		JWTRoleScopes:    getEnv("JWT_ROLE_SCOPES", "admin=admin;teacher=submit,read-all"),
+		JWTDefaultScopes: getEnv("JWT_DEFAULT_SCOPES", "submit,read-own"),
+
+		// Rate limiting
+		RateLimitEnabled:           getEnvAsBool("RATE_LIMIT_ENABLED", false),
+		RateLimitPlansFile:         getEnv("RATE_LIMIT_PLANS_FILE", ""),
+		RateLimitRequestsPerMinute: getEnvAsFloat("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+		RateLimitBurst:             getEnvAsInt("RATE_LIMIT_BURST", 20),
+		QuotaDailySubmissions:      getEnvAsInt("QUOTA_DAILY_SUBMISSIONS", 0),
+		QuotaMonthlySubmissions:    getEnvAsInt("QUOTA_MONTHLY_SUBMISSIONS", 0),
+		QuotaDailyCPUSeconds:       getEnvAsFloat("QUOTA_DAILY_CPU_SECONDS", 0),
+		QuotaMonthlyCPUSeconds:     getEnvAsFloat("QUOTA_MONTHLY_CPU_SECONDS", 0),
+
+		// Retención
+		RetentionEnabled:      getEnvAsBool("RETENTION_ENABLED", false),
+		RetentionInterval:     getEnvAsDuration("RETENTION_INTERVAL", time.Hour),
+		RetentionBatchSize:    getEnvAsInt("RETENTION_BATCH_SIZE", 500),
+		RetentionPayloadDays:  getEnvAsInt("RETENTION_PAYLOAD_DAYS", 0),
+		RetentionMetadataDays: getEnvAsInt("RETENTION_METADATA_DAYS", 0),
+		RetentionPoliciesFile: getEnv("RETENTION_POLICIES_FILE", ""),
+		RetentionArchiveDir:   getEnv("RETENTION_ARCHIVE_DIR", ""),
+
+		// Blobs
+		BlobBackend:       getEnv("BLOB_BACKEND", ""),
+		BlobThreshold:     getEnvAsInt("BLOB_THRESHOLD_BYTES", 64*1024),
+		BlobDelivery:      getEnv("BLOB_DELIVERY", "inline"),
+		BlobLinkTTL:       getEnvAsDuration("BLOB_LINK_TTL", 15*time.Minute),
+		BlobFSDir:         getEnv("BLOB_FS_DIR", "./data/blobs"),
+		BlobPublicURL:     getEnv("BLOB_PUBLIC_URL", ""),
+		BlobSigningSecret: getEnv("BLOB_SIGNING_SECRET", ""),
+		BlobS3Endpoint:    getEnv("BLOB_S3_ENDPOINT", "https://s3.amazonaws.com"),
+		BlobS3Region:      getEnv("BLOB_S3_REGION", "us-east-1"),
+		BlobS3Bucket:      getEnv("BLOB_S3_BUCKET", ""),
+		BlobS3AccessKey:   getEnv("BLOB_S3_ACCESS_KEY", ""),
+		BlobS3SecretKey:   getEnv("BLOB_S3_SECRET_KEY", ""),
+		BlobS3PathStyle:   getEnvAsBool("BLOB_S3_PATH_STYLE", true),
+
+		// Judge0 compatibility
+		Judge0CompatEnabled: getEnvAsBool("JUDGE0_COMPAT_ENABLED", true),
+		Judge0CompatPrefix:  getEnv("JUDGE0_COMPAT_PREFIX", ""),
 	}
 
 	AppConfig = config
@@ -123,6 +272,19 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		log.Printf("Warning: Invalid number value for %s, using default: %v", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -136,6 +298,41 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return value
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Warning: Invalid boolean value for %s, using default: %v", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsIntList(key string) []int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return nil
+	}
+
+	var values []int
+	for _, part := range strings.Split(valueStr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			log.Printf("Warning: Invalid integer %q in %s, skipping", part, key)
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
 // IsDevelopment verifica si estamos en modo desarrollo
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
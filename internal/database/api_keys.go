@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// apiKeyColumns son las columnas que lee scanAPIKey, en orden
+const apiKeyColumns = `id, name, key_prefix, key_hash, scopes, plan, tenant_id, created_at, last_used_at, revoked_at`
+
+// scanAPIKey lee una fila con apiKeyColumns
+func (db *DB) scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	var tenantID sql.NullString
+
+	err := row.Scan(
+		&key.ID, &key.Name, &key.Prefix, &key.Hash, db.dialect.array(&key.Scopes), &key.Plan, &tenantID,
+		&key.CreatedAt, &lastUsedAt, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.TenantID = tenantID.String
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
+
+// CreateAPIKey guarda una API key nueva (solo su hash)
+func (db *DB) CreateAPIKey(key *models.APIKey) error {
+	query := `
+	INSERT INTO api_keys (id, name, key_prefix, key_hash, scopes, plan, tenant_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at
+	`
+	err := db.conn.QueryRow(query, key.ID, key.Name, key.Prefix, key.Hash, db.dialect.array(key.Scopes), key.Plan, nullString(key.TenantID)).Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash busca una API key por el hash de la key
+func (db *DB) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := db.scanAPIKey(db.conn.QueryRow(query, hash))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("api key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys retorna las API keys (de un tenant si tenantID no es vacío),
+// las más recientes primero
+func (db *DB) ListAPIKeys(tenantID string) ([]models.APIKey, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE ($1 = '' OR tenant_id = $1) ORDER BY created_at DESC`, tenantID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := db.scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revoca una API key (solo si es del tenant, cuando tenantID no es vacío).
+// Retorna false si no existe o ya estaba revocada.
+func (db *DB) RevokeAPIKey(id, tenantID string) (bool, error) {
+	result, err := db.conn.Exec(
+		`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL AND ($2 = '' OR tenant_id = $2)`, id, tenantID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// TouchAPIKey registra el último uso de una key (como máximo una escritura por minuto)
+func (db *DB) TouchAPIKey(id string) error {
+	now := time.Now()
+	_, err := db.conn.Exec(`
+	UPDATE api_keys SET last_used_at = $2
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`, id, now, now.Add(-time.Minute))
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/blob"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// blobPrefix marca los textos guardados fuera de línea en el blob store:
+// "\x01blob:sha256:<hash>:<bytes>"
+const blobPrefix = "\x01blob:sha256:"
+
+// blobTimeout es el tiempo máximo de una operación contra el blob store
+const blobTimeout = 30 * time.Second
+
+// blobMarker arma la referencia que se guarda en la columna
+func blobMarker(key string, size int64) string {
+	return blobPrefix + key + ":" + strconv.FormatInt(size, 10)
+}
+
+// parseBlobMarker interpreta una referencia guardada con blobMarker
+func parseBlobMarker(value string) (key string, size int64, ok bool) {
+	if !strings.HasPrefix(value, blobPrefix) {
+		return "", 0, false
+	}
+	key, sizeText, found := strings.Cut(strings.TrimPrefix(value, blobPrefix), ":")
+	if !found || !blob.ValidKey(key) {
+		return "", 0, false
+	}
+	size, err := strconv.ParseInt(sizeText, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return key, size, true
+}
+
+// storeText prepara un texto para su columna: los que superan BLOB_THRESHOLD_BYTES
+// se suben al blob store y en la columna queda solo la referencia
+func (db *DB) storeText(value string) (string, error) {
+	if db.blobs == nil || len(value) <= db.blobThreshold {
+		return encodeText(value), nil
+	}
+
+	data := []byte(value)
+	key := blob.Key(data)
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+
+	if err := db.blobs.Put(ctx, key, data); err != nil {
+		return "", fmt.Errorf("failed to offload payload: %w", err)
+	}
+	return blobMarker(key, int64(len(data))), nil
+}
+
+// storeTexts aplica storeText a varios valores, en orden
+func (db *DB) storeTexts(values ...string) ([]string, error) {
+	stored := make([]string, len(values))
+	for i, value := range values {
+		var err error
+		if stored[i], err = db.storeText(value); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// storeOutput es storeText para una salida. Si la submission se leyó con links
+// (la salida vino vacía) se conserva la referencia existente en vez de borrarla.
+func (db *DB) storeOutput(sub *models.Submission, field, value string) (string, error) {
+	if ref, ok := sub.Blobs[field]; ok && value == "" {
+		return blobMarker(ref.Hash, ref.Size), nil
+	}
+	return db.storeText(value)
+}
+
+// blobFields son los campos de una submission que pueden guardarse en el blob store
+type blobField struct {
+	name   string
+	target *string
+	output bool
+}
+
+func blobFields(sub *models.Submission) []blobField {
+	return []blobField{
+		{"source_code", &sub.SourceCode, false},
+		{"stdin", &sub.Stdin, false},
+		{"expected_output", &sub.ExpectedOut, false},
+		{"stdout", &sub.Stdout, true},
+		{"stderr", &sub.Stderr, true},
+		{"compile_output", &sub.CompileOut, true},
+	}
+}
+
+// loadBlobs resuelve las referencias de una submission leída de la base. El
+// código y las entradas siempre se rehidratan (los workers los necesitan); las
+// salidas se rehidratan o, con BLOB_DELIVERY=link, se reemplazan por URLs firmadas.
+func (db *DB) loadBlobs(sub *models.Submission) error {
+	return db.resolveBlobs(sub, db.blobLinks, false)
+}
+
+// resolveBlobs reemplaza las referencias de sub por su contenido o, con links,
+// las salidas por URLs firmadas. Con skipMissing un blob que ya no existe deja
+// el campo vacío en vez de fallar.
+func (db *DB) resolveBlobs(sub *models.Submission, links, skipMissing bool) error {
+	var ctx context.Context
+	for _, field := range blobFields(sub) {
+		key, size, ok := parseBlobMarker(*field.target)
+		if !ok {
+			continue
+		}
+		if db.blobs == nil {
+			return fmt.Errorf("submission %s has an offloaded %s but BLOB_BACKEND is not configured", sub.ID, field.name)
+		}
+		if ctx == nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), blobTimeout)
+			defer cancel()
+		}
+
+		if field.output && links {
+			url, err := db.blobs.SignedURL(ctx, key, db.blobLinkTTL)
+			if err != nil {
+				return fmt.Errorf("failed to sign %s link: %w", field.name, err)
+			}
+			if sub.Blobs == nil {
+				sub.Blobs = make(map[string]models.BlobRef)
+			}
+			sub.Blobs[field.name] = models.BlobRef{
+				Hash:      key,
+				Size:      size,
+				URL:       url,
+				ExpiresAt: time.Now().Add(db.blobLinkTTL),
+			}
+			*field.target = ""
+			continue
+		}
+
+		data, err := db.blobs.Get(ctx, key)
+		if errors.Is(err, blob.ErrNotFound) && skipMissing {
+			log.Printf("⚠️  Blob %s (%s of submission %s) not found", key, field.name, sub.ID)
+			*field.target = ""
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", field.name, err)
+		}
+		*field.target = string(data)
+	}
+	return nil
+}
+
+// loadAllBlobs aplica loadBlobs a una lista de submissions
+func (db *DB) loadAllBlobs(subs []models.Submission) error {
+	for i := range subs {
+		if err := db.loadBlobs(&subs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blobColumns son las columnas que pueden guardar referencias a blobs
+var blobColumns = []string{"source_code", "stdin", "expected_output", "stdout", "stderr", "compile_output"}
+
+// querier es implementado por *sql.DB y *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// blobMarkersOf retorna las referencias a blobs (sin repetir) que aparecen en
+// las columnas de las filas que selecciona where
+func blobMarkersOf(conn querier, where string, args ...interface{}) (map[string]bool, error) {
+	columns := make([]string, len(blobColumns))
+	for i, column := range blobColumns {
+		columns[i] = "COALESCE(" + column + ", '')"
+	}
+
+	rows, err := conn.Query(`SELECT `+strings.Join(columns, ", ")+` FROM submissions WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find blob references: %w", err)
+	}
+	defer rows.Close()
+
+	markers := make(map[string]bool)
+	values := make([]string, len(blobColumns))
+	targets := make([]interface{}, len(values))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return nil, fmt.Errorf("failed to scan blob references: %w", err)
+		}
+		for _, value := range values {
+			if _, _, ok := parseBlobMarker(value); ok {
+				markers[value] = true
+			}
+		}
+	}
+	return markers, rows.Err()
+}
+
+// submissionBlobMarkers retorna las referencias a blobs de las submissions indicadas
+func (db *DB) submissionBlobMarkers(conn querier, ids []string) (map[string]bool, error) {
+	if db.blobs == nil || len(ids) == 0 {
+		return nil, nil
+	}
+	list, args := idList(ids, 0)
+	return blobMarkersOf(conn, "id IN ("+list+")", args...)
+}
+
+// releaseBlobs borra del blob store los blobs de markers que ya no referencia
+// ninguna submission. Como el contenido se comparte entre submissions, solo se
+// borran después de quitar las referencias y de confirmar que no quedan otras.
+// Los errores se registran pero no se retornan: las filas ya se modificaron.
+func (db *DB) releaseBlobs(markers map[string]bool) {
+	if db.blobs == nil || len(markers) == 0 {
+		return
+	}
+
+	candidates := make([]string, 0, len(markers))
+	for marker := range markers {
+		candidates = append(candidates, marker)
+	}
+	list, args := idList(candidates, 0)
+	conditions := make([]string, len(blobColumns))
+	for i, column := range blobColumns {
+		conditions[i] = column + " IN (" + list + ")"
+	}
+	referenced, err := blobMarkersOf(db.conn, strings.Join(conditions, " OR "), args...)
+	if err != nil {
+		log.Printf("⚠️  Failed to check blob references: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+
+	deleted := 0
+	for _, marker := range candidates {
+		if referenced[marker] {
+			continue
+		}
+		key, _, _ := parseBlobMarker(marker)
+		if err := db.blobs.Delete(ctx, key); err != nil {
+			log.Printf("⚠️  Failed to delete blob %s: %v", key, err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d unreferenced blob(s)", deleted)
+	}
+}
@@ -4,200 +4,117 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/RobertoRochaT/rojudger/internal/blob"
 	"github.com/RobertoRochaT/rojudger/internal/config"
 	"github.com/RobertoRochaT/rojudger/internal/models"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
-// DB es el wrapper de la base de datos
+// DB es el wrapper de la base de datos. Implementa Store sobre PostgreSQL o SQLite.
 type DB struct {
-	conn *sql.DB
+	conn           *sql.DB
+	dialect        dialect
+	idempotencyTTL time.Duration
+
+	// Payloads grandes fuera de línea (nil = todo en la base)
+	blobs         blob.Store
+	blobThreshold int
+	blobLinks     bool
+	blobLinkTTL   time.Duration
 }
 
-// NewDB crea una nueva conexión a la base de datos
+// NewDB crea una nueva conexión a la base de datos del motor configurado (DB_DRIVER)
 func NewDB(cfg *config.Config) (*DB, error) {
-	dsn := cfg.GetDatabaseDSN()
+	var conn *sql.DB
+	var d dialect
+	var err error
 
-	conn, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
+	switch cfg.DBDriver {
+	case DriverPostgres, "":
+		conn, err = sql.Open("postgres", cfg.GetDatabaseDSN())
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
 
-	// Configurar pool de conexiones
-	conn.SetMaxOpenConns(25)
-	conn.SetMaxIdleConns(5)
-	conn.SetConnMaxLifetime(5 * time.Minute)
+		// Configurar pool de conexiones
+		conn.SetMaxOpenConns(25)
+		conn.SetMaxIdleConns(5)
+		conn.SetConnMaxLifetime(5 * time.Minute)
+		d = postgresDialect{}
+
+	case DriverSQLite:
+		conn, err = sql.Open("sqlite", sqliteDSN(cfg.SQLitePath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+
+		// SQLite admite un solo escritor: una conexión evita errores "database is locked"
+		conn.SetMaxOpenConns(1)
+		d = sqliteDialect{}
+
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q (use %s or %s)", cfg.DBDriver, DriverPostgres, DriverSQLite)
+	}
 
 	// Verificar conexión
 	if err := conn.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("Database connected successfully")
-
-	return &DB{conn: conn}, nil
-}
-
-// InitSchema crea las tablas necesarias si no existen
-func (db *DB) InitSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS languages (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(50) NOT NULL UNIQUE,
-		display_name VARCHAR(100) NOT NULL,
-		version VARCHAR(50) NOT NULL,
-		extension VARCHAR(10) NOT NULL,
-		compile_cmd TEXT,
-		execute_cmd TEXT NOT NULL,
-		docker_image VARCHAR(200) NOT NULL,
-		is_compiled BOOLEAN DEFAULT FALSE,
-		is_enabled BOOLEAN DEFAULT TRUE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS submissions (
-		id VARCHAR(36) PRIMARY KEY,
-		language_id INTEGER NOT NULL REFERENCES languages(id),
-		source_code TEXT NOT NULL,
-		stdin TEXT,
-		expected_output TEXT,
-		status VARCHAR(20) NOT NULL DEFAULT 'queued',
-		stdout TEXT,
-		stderr TEXT,
-		exit_code INTEGER DEFAULT -1,
-		time DOUBLE PRECISION DEFAULT 0,
-		memory INTEGER DEFAULT 0,
-		compile_output TEXT,
-		message TEXT,
-		webhook_url TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		finished_at TIMESTAMP,
-		CONSTRAINT fk_language FOREIGN KEY (language_id) REFERENCES languages(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_submissions_status ON submissions(status);
-	CREATE INDEX IF NOT EXISTS idx_submissions_created_at ON submissions(created_at DESC);
-	CREATE INDEX IF NOT EXISTS idx_submissions_language ON submissions(language_id);
-
-	CREATE TABLE IF NOT EXISTS webhook_logs (
-		id SERIAL PRIMARY KEY,
-		submission_id VARCHAR(36) NOT NULL,
-		webhook_url TEXT NOT NULL,
-		attempt INTEGER NOT NULL DEFAULT 1,
-		status_code INTEGER,
-		response_body TEXT,
-		error TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		CONSTRAINT fk_webhook_submission FOREIGN KEY (submission_id) REFERENCES submissions(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_webhook_logs_submission ON webhook_logs(submission_id);
-	CREATE INDEX IF NOT EXISTS idx_webhook_logs_created_at ON webhook_logs(created_at DESC);
-	`
+	log.Printf("Database connected successfully (%s)", cfg.DBDriver)
 
-	_, err := db.conn.Exec(schema)
+	// Blob store para payloads grandes
+	if cfg.BlobDelivery != blob.DeliveryInline && cfg.BlobDelivery != blob.DeliveryLink {
+		conn.Close()
+		return nil, fmt.Errorf("unsupported BLOB_DELIVERY %q (use %s or %s)", cfg.BlobDelivery, blob.DeliveryInline, blob.DeliveryLink)
+	}
+	blobs, err := blob.New(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create schema: %w", err)
+		conn.Close()
+		return nil, fmt.Errorf("failed to create blob store: %w", err)
+	}
+	if blobs != nil {
+		log.Printf("Payloads over %d bytes are offloaded to the %s blob store (%s)", cfg.BlobThreshold, cfg.BlobBackend, cfg.BlobDelivery)
 	}
 
-	log.Println("Database schema initialized")
-	return nil
+	return &DB{
+		conn:           conn,
+		dialect:        d,
+		idempotencyTTL: cfg.IdempotencyTTL,
+		blobs:          blobs,
+		blobThreshold:  cfg.BlobThreshold,
+		blobLinks:      cfg.BlobDelivery == blob.DeliveryLink,
+		blobLinkTTL:    cfg.BlobLinkTTL,
+	}, nil
 }
 
-// SeedLanguages inserta los lenguajes iniciales si no existen
-func (db *DB) SeedLanguages() error {
-	languages := []models.Language{
-		{
-			ID:          models.LanguagePython3,
-			Name:        "python3",
-			DisplayName: "Python 3",
-			Version:     "3.11",
-			Extension:   ".py",
-			ExecuteCmd:  "python3 {file}",
-			DockerImage: "python:3.11-slim",
-			IsCompiled:  false,
-			IsEnabled:   true,
-		},
-		{
-			ID:          models.LanguageJavaScript,
-			Name:        "javascript",
-			DisplayName: "JavaScript (Node.js)",
-			Version:     "20",
-			Extension:   ".js",
-			ExecuteCmd:  "node {file}",
-			DockerImage: "node:20-slim",
-			IsCompiled:  false,
-			IsEnabled:   true,
-		},
-		{
-			ID:          models.LanguageGo,
-			Name:        "go",
-			DisplayName: "Go",
-			Version:     "1.21",
-			Extension:   ".go",
-			ExecuteCmd:  "go run {file}",
-			DockerImage: "golang:1.21-alpine",
-			IsCompiled:  false, // go run compila y ejecuta
-			IsEnabled:   true,
-		},
-		{
-			ID:          models.LanguageC,
-			Name:        "c",
-			DisplayName: "C (GCC)",
-			Version:     "11",
-			Extension:   ".c",
-			CompileCmd:  "gcc {file} -o main",
-			ExecuteCmd:  "./main",
-			DockerImage: "gcc:11",
-			IsCompiled:  true,
-			IsEnabled:   true,
-		},
-		{
-			ID:          models.LanguageCPP,
-			Name:        "cpp",
-			DisplayName: "C++ (G++)",
-			Version:     "11",
-			Extension:   ".cpp",
-			CompileCmd:  "g++ {file} -o main",
-			ExecuteCmd:  "./main",
-			DockerImage: "gcc:11",
-			IsCompiled:  true,
-			IsEnabled:   true,
-		},
-	}
-
-	for _, lang := range languages {
-		// Insertar solo si no existe
-		query := `
-		INSERT INTO languages (id, name, display_name, version, extension, compile_cmd, execute_cmd, docker_image, is_compiled, is_enabled)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (name) DO NOTHING
-		`
-		_, err := db.conn.Exec(query,
-			lang.ID, lang.Name, lang.DisplayName, lang.Version,
-			lang.Extension, lang.CompileCmd, lang.ExecuteCmd,
-			lang.DockerImage, lang.IsCompiled, lang.IsEnabled,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to seed language %s: %w", lang.Name, err)
-		}
-	}
-
-	log.Println("Languages seeded successfully")
-	return nil
+// sqliteDSN arma la conexión a un archivo SQLite (":memory:" = en memoria).
+// Los TIMESTAMP se guardan en formato SQLite para que strftime y las
+// comparaciones funcionen.
+func sqliteDSN(path string) string {
+	return "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_time_format=sqlite"
 }
 
 // CreateSubmission inserta una nueva submission en la base de datos
 func (db *DB) CreateSubmission(sub *models.Submission) error {
 	query := `
-	INSERT INTO submissions (id, language_id, source_code, stdin, expected_output, status, webhook_url, created_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	INSERT INTO submissions (id, language_id, source_code, stdin, expected_output, status, webhook_url, created_at, scheduled_at, problem_id, user_id, api_key_id, tenant_id, priority, quota_key)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
-	_, err := db.conn.Exec(query,
-		sub.ID, sub.LanguageID, sub.SourceCode, sub.Stdin,
-		sub.ExpectedOut, sub.Status, sub.WebhookURL, sub.CreatedAt,
+	texts, err := db.storeTexts(sub.SourceCode, sub.Stdin, sub.ExpectedOut)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.conn.Exec(query,
+		sub.ID, sub.LanguageID, texts[0], texts[1], texts[2],
+		sub.Status, sub.WebhookURL, sub.CreatedAt, sub.ScheduledAt,
+		nullString(sub.ProblemID), nullString(sub.UserID), nullString(sub.APIKeyID), nullString(sub.TenantID),
+		sub.Priority, nullString(sub.QuotaKey),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create submission: %w", err)
@@ -205,44 +122,50 @@ func (db *DB) CreateSubmission(sub *models.Submission) error {
 	return nil
 }
 
-// GetSubmission obtiene una submission por ID
-func (db *DB) GetSubmission(id string) (*models.Submission, error) {
-	query := `
-	SELECT id, language_id, source_code, stdin, expected_output, status,
+// submissionColumns son las columnas que lee scanSubmission, en orden
+const submissionColumns = `id, language_id, source_code, stdin, expected_output, status,
 	       stdout, stderr, exit_code, time, memory, compile_output, message,
-	       webhook_url, created_at, finished_at
-	FROM submissions
-	WHERE id = $1
-	`
+	       webhook_url, created_at, finished_at, scheduled_at, problem_id, user_id, api_key_id, tenant_id,
+	       payload_purged_at, priority, quota_key`
+
+// rowScanner es implementado por *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSubmission lee una fila con submissionColumns manejando los campos NULL
+func scanSubmission(row rowScanner) (*models.Submission, error) {
 	var sub models.Submission
-	var finishedAt sql.NullTime
-	var stdout, stderr, compileOut, message, webhookURL sql.NullString
+	var finishedAt, scheduledAt, payloadPurgedAt sql.NullTime
+	var stdout, stderr, compileOut, message, webhookURL, problemID, userID, apiKeyID, tenantID, quotaKey sql.NullString
 
-	err := db.conn.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&sub.ID, &sub.LanguageID, &sub.SourceCode, &sub.Stdin, &sub.ExpectedOut,
 		&sub.Status, &stdout, &stderr, &sub.ExitCode, &sub.Time,
-		&sub.Memory, &compileOut, &message, &webhookURL, &sub.CreatedAt, &finishedAt,
+		&sub.Memory, &compileOut, &message, &webhookURL, &sub.CreatedAt, &finishedAt, &scheduledAt,
+		&problemID, &userID, &apiKeyID, &tenantID, &payloadPurgedAt, &sub.Priority, &quotaKey,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("submission not found")
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get submission: %w", err)
+		return nil, err
 	}
 
+	// Los textos pueden estar guardados en base64 (ver encodeText)
+	sub.SourceCode = decodeText(sub.SourceCode)
+	sub.Stdin = decodeText(sub.Stdin)
+	sub.ExpectedOut = decodeText(sub.ExpectedOut)
+
 	// Handle nullable fields
 	if stdout.Valid {
-		sub.Stdout = stdout.String
+		sub.Stdout = decodeText(stdout.String)
 	}
 	if stderr.Valid {
-		sub.Stderr = stderr.String
+		sub.Stderr = decodeText(stderr.String)
 	}
 	if compileOut.Valid {
-		sub.CompileOut = compileOut.String
+		sub.CompileOut = decodeText(compileOut.String)
 	}
 	if message.Valid {
-		sub.Message = message.String
+		sub.Message = decodeText(message.String)
 	}
 	if webhookURL.Valid {
 		sub.WebhookURL = webhookURL.String
@@ -250,104 +173,183 @@ func (db *DB) GetSubmission(id string) (*models.Submission, error) {
 	if finishedAt.Valid {
 		sub.FinishedAt = &finishedAt.Time
 	}
+	if payloadPurgedAt.Valid {
+		sub.PayloadPurgedAt = &payloadPurgedAt.Time
+	}
+	if scheduledAt.Valid {
+		sub.ScheduledAt = &scheduledAt.Time
+	}
+	sub.ProblemID = problemID.String
+	sub.UserID = userID.String
+	sub.APIKeyID = apiKeyID.String
+	sub.TenantID = tenantID.String
+	sub.QuotaKey = quotaKey.String
 
 	return &sub, nil
 }
 
-// UpdateSubmission actualiza una submission existente
-func (db *DB) UpdateSubmission(sub *models.Submission) error {
-	query := `
-	UPDATE submissions
-	SET status = $1, stdout = $2, stderr = $3, exit_code = $4,
-	    time = $5, memory = $6, compile_output = $7, message = $8, finished_at = $9
-	WHERE id = $10
-	`
-	_, err := db.conn.Exec(query,
-		sub.Status, sub.Stdout, sub.Stderr, sub.ExitCode,
-		sub.Time, sub.Memory, sub.CompileOut, sub.Message, sub.FinishedAt, sub.ID,
-	)
+// GetSubmission obtiene una submission por ID
+func (db *DB) GetSubmission(id string) (*models.Submission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM submissions WHERE id = $1`
+
+	sub, err := scanSubmission(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("submission not found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update submission: %w", err)
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if err := db.loadBlobs(sub); err != nil {
+		return nil, err
 	}
-	return nil
-}
-
-// GetLanguage obtiene un lenguaje por ID
-func (db *DB) GetLanguage(id int) (*models.Language, error) {
-	query := `
-	SELECT id, name, display_name, version, extension, compile_cmd,
-	       execute_cmd, docker_image, is_compiled, is_enabled
-	FROM languages
-	WHERE id = $1 AND is_enabled = true
-	`
-	var lang models.Language
-	var compileCmd sql.NullString
 
-	err := db.conn.QueryRow(query, id).Scan(
-		&lang.ID, &lang.Name, &lang.DisplayName, &lang.Version,
-		&lang.Extension, &compileCmd, &lang.ExecuteCmd,
-		&lang.DockerImage, &lang.IsCompiled, &lang.IsEnabled,
-	)
+	return sub, nil
+}
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("language not found or disabled")
+// GetSubmissionsByIDs obtiene varias submissions a la vez.
+// El resultado no respeta el orden de ids y omite los que no existen.
+func (db *DB) GetSubmissionsByIDs(ids []string) ([]models.Submission, error) {
+	submissions, err := db.querySubmissionsByIDs(ids)
+	if err != nil {
+		return nil, err
 	}
+	return submissions, db.loadAllBlobs(submissions)
+}
+
+// GetSubmissionsForArchive es GetSubmissionsByIDs con todo el contenido: las
+// salidas se rehidratan aunque BLOB_DELIVERY=link, y un blob que ya no existe
+// deja su campo vacío en vez de fallar todo el lote
+func (db *DB) GetSubmissionsForArchive(ids []string) ([]models.Submission, error) {
+	submissions, err := db.querySubmissionsByIDs(ids)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get language: %w", err)
+		return nil, err
+	}
+	for i := range submissions {
+		if err := db.resolveBlobs(&submissions[i], false, true); err != nil {
+			return nil, err
+		}
 	}
+	return submissions, nil
+}
 
-	if compileCmd.Valid {
-		lang.CompileCmd = compileCmd.String
+func (db *DB) querySubmissionsByIDs(ids []string) ([]models.Submission, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	return &lang, nil
-}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	query := `SELECT ` + submissionColumns + ` FROM submissions WHERE id IN (` + strings.Join(placeholders, ", ") + `)`
 
-// GetAllLanguages obtiene todos los lenguajes habilitados
-func (db *DB) GetAllLanguages() ([]models.Language, error) {
-	query := `
-	SELECT id, name, display_name, version, extension, compile_cmd,
-	       execute_cmd, docker_image, is_compiled, is_enabled
-	FROM languages
-	WHERE is_enabled = true
-	ORDER BY id
-	`
-	rows, err := db.conn.Query(query)
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get languages: %w", err)
+		return nil, fmt.Errorf("failed to get submissions: %w", err)
 	}
 	defer rows.Close()
 
-	var languages []models.Language
+	var submissions []models.Submission
 	for rows.Next() {
-		var lang models.Language
-		var compileCmd sql.NullString
+		sub, err := scanSubmission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan submission: %w", err)
+		}
+		submissions = append(submissions, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get submissions: %w", err)
+	}
+	return submissions, nil
+}
 
-		err := rows.Scan(
-			&lang.ID, &lang.Name, &lang.DisplayName, &lang.Version,
-			&lang.Extension, &compileCmd, &lang.ExecuteCmd,
-			&lang.DockerImage, &lang.IsCompiled, &lang.IsEnabled,
-		)
+// CreateSubmissions inserta varias submissions en una sola transacción
+func (db *DB) CreateSubmissions(subs []*models.Submission) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO submissions (id, language_id, source_code, stdin, expected_output, status, webhook_url, created_at, scheduled_at, problem_id, user_id, api_key_id, tenant_id, priority, quota_key)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, sub := range subs {
+		texts, err := db.storeTexts(sub.SourceCode, sub.Stdin, sub.ExpectedOut)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan language: %w", err)
+			return err
 		}
 
-		if compileCmd.Valid {
-			lang.CompileCmd = compileCmd.String
+		_, err = stmt.Exec(
+			sub.ID, sub.LanguageID, texts[0], texts[1], texts[2],
+			sub.Status, sub.WebhookURL, sub.CreatedAt, sub.ScheduledAt,
+			nullString(sub.ProblemID), nullString(sub.UserID), nullString(sub.APIKeyID), nullString(sub.TenantID),
+			sub.Priority, nullString(sub.QuotaKey),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create submission %s: %w", sub.ID, err)
 		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit submissions: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubmission actualiza una submission existente
+func (db *DB) UpdateSubmission(sub *models.Submission) error {
+	query := `
+	UPDATE submissions
+	SET status = $1, stdout = $2, stderr = $3, exit_code = $4,
+	    time = $5, memory = $6, compile_output = $7, message = $8, finished_at = $9
+	WHERE id = $10
+	`
+	stdout, err := db.storeOutput(sub, "stdout", sub.Stdout)
+	if err != nil {
+		return err
+	}
+	stderr, err := db.storeOutput(sub, "stderr", sub.Stderr)
+	if err != nil {
+		return err
+	}
+	compileOut, err := db.storeOutput(sub, "compile_output", sub.CompileOut)
+	if err != nil {
+		return err
+	}
 
-		languages = append(languages, lang)
+	_, err = db.conn.Exec(query,
+		sub.Status, stdout, stderr, sub.ExitCode,
+		sub.Time, sub.Memory, compileOut, encodeText(sub.Message), sub.FinishedAt, sub.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update submission: %w", err)
 	}
+	return nil
+}
 
-	return languages, nil
+// UpdateSubmissionStatus cambia solo el estado de una submission
+func (db *DB) UpdateSubmissionStatus(id, status string) error {
+	query := `UPDATE submissions SET status = $1 WHERE id = $2`
+	_, err := db.conn.Exec(query, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update submission status: %w", err)
+	}
+	return nil
 }
 
 // GetSubmissionsByStatus obtiene submissions por estado
 func (db *DB) GetSubmissionsByStatus(status string, limit int) ([]models.Submission, error) {
 	query := `
-	SELECT id, language_id, source_code, stdin, expected_output, status,
-	       stdout, stderr, exit_code, time, memory, compile_output, message,
-	       created_at, finished_at
+	SELECT ` + submissionColumns + `
 	FROM submissions
 	WHERE status = $1
 	ORDER BY created_at ASC
@@ -361,40 +363,55 @@ func (db *DB) GetSubmissionsByStatus(status string, limit int) ([]models.Submiss
 
 	var submissions []models.Submission
 	for rows.Next() {
-		var sub models.Submission
-		var finishedAt sql.NullTime
-		var stdout, stderr, compileOut, message sql.NullString
-
-		err := rows.Scan(
-			&sub.ID, &sub.LanguageID, &sub.SourceCode, &sub.Stdin, &sub.ExpectedOut,
-			&sub.Status, &stdout, &stderr, &sub.ExitCode, &sub.Time,
-			&sub.Memory, &compileOut, &message, &sub.CreatedAt, &finishedAt,
-		)
+		sub, err := scanSubmission(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan submission: %w", err)
 		}
 
-		// Handle nullable fields
-		if stdout.Valid {
-			sub.Stdout = stdout.String
-		}
-		if stderr.Valid {
-			sub.Stderr = stderr.String
-		}
-		if compileOut.Valid {
-			sub.CompileOut = compileOut.String
-		}
-		if message.Valid {
-			sub.Message = message.String
-		}
-		if finishedAt.Valid {
-			sub.FinishedAt = &finishedAt.Time
-		}
+		submissions = append(submissions, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get submissions: %w", err)
+	}
+
+	return submissions, db.loadAllBlobs(submissions)
+}
 
-		submissions = append(submissions, sub)
+// ListSubmissions obtiene una página de submissions, las más recientes primero.
+// scope la limita a lo que puede ver el cliente.
+func (db *DB) ListSubmissions(scope SubmissionScope, offset, limit int) ([]models.Submission, error) {
+	where, args := scope.conditions()
+	query := `SELECT ` + submissionColumns + ` FROM submissions` + where +
+		fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	rows, err := db.conn.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
 	}
+	defer rows.Close()
 
-	return submissions, nil
+	submissions := []models.Submission{}
+	for rows.Next() {
+		sub, err := scanSubmission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan submission: %w", err)
+		}
+		submissions = append(submissions, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+
+	return submissions, db.loadAllBlobs(submissions)
+}
+
+// CountSubmissions retorna el total de submissions con los mismos filtros que ListSubmissions
+func (db *DB) CountSubmissions(scope SubmissionScope) (int, error) {
+	var count int
+	where, args := scope.conditions()
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM submissions`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count submissions: %w", err)
+	}
+	return count, nil
 }
 
 // Close cierra la conexión a la base de datos
@@ -413,9 +430,53 @@ func (db *DB) LogWebhookAttempt(submissionID, webhookURL string, attempt, status
 	INSERT INTO webhook_logs (submission_id, webhook_url, attempt, status_code, response_body, error)
 	VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	_, err := db.conn.Exec(query, submissionID, webhookURL, attempt, statusCode, responseBody, errorMsg)
+	_, err := db.conn.Exec(query, submissionID, webhookURL, attempt, statusCode, encodeText(responseBody), errorMsg)
 	if err != nil {
 		return fmt.Errorf("failed to log webhook attempt: %w", err)
 	}
 	return nil
 }
+
+// ReserveIdempotencyKey intenta registrar un Idempotency-Key para una nueva submission.
+// Si el key ya existe (y no expiró) retorna el registro existente y false.
+func (db *DB) ReserveIdempotencyKey(key, fingerprint, submissionID string) (*models.IdempotencyKey, bool, error) {
+	// Liberar el key si ya expiró
+	expiredBefore := time.Now().Add(-db.idempotencyTTL)
+	if _, err := db.conn.Exec(`DELETE FROM idempotency_keys WHERE key = $1 AND created_at < $2`, key, expiredBefore); err != nil {
+		return nil, false, fmt.Errorf("failed to expire idempotency key: %w", err)
+	}
+
+	query := `
+	INSERT INTO idempotency_keys (key, fingerprint, submission_id, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (key) DO NOTHING
+	`
+	res, err := db.conn.Exec(query, key, fingerprint, submissionID, time.Now())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if inserted, _ := res.RowsAffected(); inserted == 1 {
+		return nil, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	err = db.conn.QueryRow(`
+	SELECT key, fingerprint, submission_id, created_at
+	FROM idempotency_keys
+	WHERE key = $1
+	`, key).Scan(&existing.Key, &existing.Fingerprint, &existing.SubmissionID, &existing.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &existing, false, nil
+}
+
+// DeleteIdempotencyKey libera un Idempotency-Key (p.ej. si falló la creación)
+func (db *DB) DeleteIdempotencyKey(key string) error {
+	_, err := db.conn.Exec(`DELETE FROM idempotency_keys WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
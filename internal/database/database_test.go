@@ -0,0 +1,95 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+func TestCreateAndGetSubmission(t *testing.T) {
+	db := newTestDB(t)
+
+	sub := &models.Submission{
+		ID:         "s1",
+		LanguageID: 71,
+		SourceCode: "print('hola')",
+		Stdin:      "entrada",
+		Status:     models.StatusQueued,
+		ExitCode:   -1,
+		CreatedAt:  time.Now().UTC(),
+		UserID:     "alumno-1",
+		Priority:   8,
+		QuotaKey:   "key-1",
+	}
+	if err := db.CreateSubmission(sub); err != nil {
+		t.Fatalf("CreateSubmission: %v", err)
+	}
+
+	got, err := db.GetSubmission("s1")
+	if err != nil {
+		t.Fatalf("GetSubmission: %v", err)
+	}
+	if got.SourceCode != sub.SourceCode || got.Stdin != sub.Stdin || got.UserID != sub.UserID {
+		t.Fatalf("GetSubmission = %+v, want the stored fields of %+v", got, sub)
+	}
+	if got.Priority != 8 || got.QuotaKey != "key-1" {
+		t.Fatalf("job fields = (%d, %q), want (8, key-1)", got.Priority, got.QuotaKey)
+	}
+	if got.CreatedAt.Sub(sub.CreatedAt).Abs() > time.Millisecond {
+		t.Fatalf("created_at = %v, want %v", got.CreatedAt, sub.CreatedAt)
+	}
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db := newTestDB(t)
+	newTestSubmission(t, db, "s1", time.Now())
+
+	existing, reserved, err := db.ReserveIdempotencyKey("key-1", "fingerprint", "s1")
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if !reserved || existing != nil {
+		t.Fatalf("first reservation = (%+v, %v), want a new reservation", existing, reserved)
+	}
+
+	// Un reintento recibe la submission original
+	existing, reserved, err = db.ReserveIdempotencyKey("key-1", "other", "s2")
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if reserved || existing == nil {
+		t.Fatalf("second reservation = (%+v, %v), want the existing key", existing, reserved)
+	}
+	if existing.SubmissionID != "s1" || existing.Fingerprint != "fingerprint" {
+		t.Fatalf("existing key = %+v, want submission s1 with the original fingerprint", existing)
+	}
+
+	// Liberado, el key se puede volver a usar
+	if err := db.DeleteIdempotencyKey("key-1"); err != nil {
+		t.Fatalf("DeleteIdempotencyKey: %v", err)
+	}
+	if _, reserved, err := db.ReserveIdempotencyKey("key-1", "fingerprint", "s3"); err != nil || !reserved {
+		t.Fatalf("reservation after delete = (%v, %v), want reserved", reserved, err)
+	}
+}
+
+func TestReserveIdempotencyKeyExpires(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.IdempotencyTTL = time.Millisecond
+	db := openTestDB(t, cfg)
+
+	if _, reserved, err := db.ReserveIdempotencyKey("key-1", "fingerprint", "s1"); err != nil || !reserved {
+		t.Fatalf("first reservation = (%v, %v), want reserved", reserved, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// Vencido el TTL el key vuelve a estar libre
+	existing, reserved, err := db.ReserveIdempotencyKey("key-1", "fingerprint", "s2")
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if !reserved {
+		t.Fatalf("expired key still held by %+v", existing)
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Motores soportados (DB_DRIVER)
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// dialect agrupa lo que cambia entre motores. El resto del SQL es común: tanto
+// PostgreSQL como SQLite entienden los placeholders $N, ON CONFLICT y RETURNING.
+type dialect interface {
+	// array envuelve una lista (o un puntero a ella, para Scan) de una columna de listas
+	array(value interface{}) interface{}
+	// timestamp retorna la expresión y el argumento para comparar un TIMESTAMP con t.
+	// created_at se guarda en UTC: t debe venir en UTC.
+	timestamp(t time.Time) (string, interface{})
+	// day retorna la expresión con el día (YYYY-MM-DD) de una columna TIMESTAMP
+	day(column string) string
+	// migrationsDir es el directorio de migraciones embebidas del motor
+	migrationsDir() string
+	// lock serializa las migraciones entre procesos usando la conexión conn
+	lock(ctx context.Context, conn *sql.Conn) (unlock func(), err error)
+}
+
+// postgresDialect usa arrays nativos y advisory locks
+type postgresDialect struct{}
+
+func (postgresDialect) array(value interface{}) interface{} {
+	return pq.Array(value)
+}
+
+func (postgresDialect) timestamp(t time.Time) (string, interface{}) {
+	// created_at es TIMESTAMP sin zona: comparar con el mismo valor leído, sin convertir
+	return "?::timestamp", t.Format("2006-01-02 15:04:05.999999")
+}
+
+func (postgresDialect) day(column string) string {
+	return "TO_CHAR(" + column + ", 'YYYY-MM-DD')"
+}
+
+func (postgresDialect) migrationsDir() string {
+	return "migrations/postgres"
+}
+
+func (postgresDialect) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+	}, nil
+}
+
+// sqliteDialect guarda las listas como JSON. Los TIMESTAMP se guardan como texto
+// con formato fijo, así que se comparan directamente.
+type sqliteDialect struct{}
+
+func (sqliteDialect) array(value interface{}) interface{} {
+	return jsonArray{value}
+}
+
+func (sqliteDialect) timestamp(t time.Time) (string, interface{}) {
+	return "?", t
+}
+
+func (sqliteDialect) day(column string) string {
+	return "strftime('%Y-%m-%d', " + column + ")"
+}
+
+func (sqliteDialect) migrationsDir() string {
+	return "migrations/sqlite"
+}
+
+func (sqliteDialect) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	// El archivo lo usa un solo proceso; las transacciones de cada migración bastan
+	return func() {}, nil
+}
+
+// jsonArray guarda una lista como texto JSON (SQLite no tiene arrays)
+type jsonArray struct {
+	target interface{}
+}
+
+// Value implementa driver.Valuer
+func (a jsonArray) Value() (driver.Value, error) {
+	data, err := json.Marshal(a.target)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return "[]", nil
+	}
+	return string(data), nil
+}
+
+// Scan implementa sql.Scanner
+func (a jsonArray) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value), a.target)
+	case []byte:
+		return json.Unmarshal(value, a.target)
+	default:
+		return fmt.Errorf("cannot scan %T into a list", src)
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"encoding/base64"
+	"strings"
+	"unicode/utf8"
+)
+
+// binaryPrefix marca los valores guardados en base64 en columnas TEXT.
+// PostgreSQL rechaza bytes NUL y UTF-8 inválido, que un programa puede imprimir.
+const binaryPrefix = "\x01base64:"
+
+// encodeText prepara un valor para una columna TEXT. Los textos UTF-8 válidos se guardan
+// tal cual; los demás (o los que empiezan con binaryPrefix o blobPrefix) se guardan en base64.
+func encodeText(value string) string {
+	if utf8.ValidString(value) && !strings.ContainsRune(value, 0) &&
+		!strings.HasPrefix(value, binaryPrefix) && !strings.HasPrefix(value, blobPrefix) {
+		return value
+	}
+	return binaryPrefix + base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+// decodeText recupera los bytes originales de un valor guardado con encodeText
+func decodeText(value string) string {
+	if !strings.HasPrefix(value, binaryPrefix) {
+		return value
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, binaryPrefix))
+	if err != nil {
+		return value
+	}
+	return string(decoded)
+}
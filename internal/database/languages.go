@@ -0,0 +1,419 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// ErrLanguageChanged indica que el lenguaje cambió desde que se leyó (otra
+// petición guardó una revisión nueva)
+var ErrLanguageChanged = errors.New("language was modified concurrently")
+
+// helloWorld es la salida esperada de los smoke tests de los lenguajes iniciales
+const helloWorld = "Hello, World!"
+
+// seedLanguages son los lenguajes iniciales. SeedLanguages los mantiene al día
+// mientras ningún admin los modifique.
+var seedLanguages = []models.Language{
+	{
+		ID:              models.LanguagePython3,
+		Name:            "python3",
+		DisplayName:     "Python 3",
+		Version:         "3.11",
+		Extension:       ".py",
+		ExecuteCmd:      "python3 {file}",
+		DockerImage:     "python:3.11-slim",
+		IsCompiled:      false,
+		IsEnabled:       true,
+		SmokeTestCode:   `print("Hello, World!")`,
+		SmokeTestOutput: helloWorld,
+	},
+	{
+		ID:              models.LanguageJavaScript,
+		Name:            "javascript",
+		DisplayName:     "JavaScript (Node.js)",
+		Version:         "20",
+		Extension:       ".js",
+		ExecuteCmd:      "node {file}",
+		DockerImage:     "node:20-slim",
+		IsCompiled:      false,
+		IsEnabled:       true,
+		SmokeTestCode:   `console.log("Hello, World!");`,
+		SmokeTestOutput: helloWorld,
+	},
+	{
+		ID:          models.LanguageGo,
+		Name:        "go",
+		DisplayName: "Go",
+		Version:     "1.21",
+		Extension:   ".go",
+		ExecuteCmd:  "go run {file}",
+		DockerImage: "golang:1.21-alpine",
+		IsCompiled:  false, // go run compila y ejecuta
+		IsEnabled:   true,
+		SmokeTestCode: `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello, World!")
+}
+`,
+		SmokeTestOutput: helloWorld,
+	},
+	{
+		ID:          models.LanguageC,
+		Name:        "c",
+		DisplayName: "C (GCC)",
+		Version:     "11",
+		Extension:   ".c",
+		CompileCmd:  "gcc {file} -o main",
+		ExecuteCmd:  "./main",
+		DockerImage: "gcc:11",
+		IsCompiled:  true,
+		IsEnabled:   true,
+		SmokeTestCode: `#include <stdio.h>
+
+int main(void) {
+    printf("Hello, World!\n");
+    return 0;
+}
+`,
+		SmokeTestOutput: helloWorld,
+	},
+	{
+		ID:          models.LanguageCPP,
+		Name:        "cpp",
+		DisplayName: "C++ (G++)",
+		Version:     "11",
+		Extension:   ".cpp",
+		CompileCmd:  "g++ {file} -o main",
+		ExecuteCmd:  "./main",
+		DockerImage: "gcc:11",
+		IsCompiled:  true,
+		IsEnabled:   true,
+		SmokeTestCode: `#include <iostream>
+
+int main() {
+    std::cout << "Hello, World!" << std::endl;
+    return 0;
+}
+`,
+		SmokeTestOutput: helloWorld,
+	},
+}
+
+// SeedLanguages inserta los lenguajes iniciales y actualiza los que siguen
+// administrados por el seed (managed_by = 'seed') si su definición cambió.
+// Los lenguajes modificados por un admin no se tocan.
+func (db *DB) SeedLanguages() error {
+	for _, lang := range seedLanguages {
+		query := `
+		INSERT INTO languages (id, name, display_name, version, extension, compile_cmd, execute_cmd, docker_image,
+		                       is_compiled, is_enabled, smoke_test_code, smoke_test_output, revision, managed_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 1, $13, $14)
+		ON CONFLICT (name) DO UPDATE SET
+			display_name = excluded.display_name, version = excluded.version, extension = excluded.extension,
+			compile_cmd = excluded.compile_cmd, execute_cmd = excluded.execute_cmd, docker_image = excluded.docker_image,
+			is_compiled = excluded.is_compiled, is_enabled = excluded.is_enabled,
+			smoke_test_code = excluded.smoke_test_code, smoke_test_output = excluded.smoke_test_output,
+			revision = languages.revision + 1, updated_at = excluded.updated_at
+		WHERE languages.managed_by = $13 AND (
+			languages.display_name <> excluded.display_name OR languages.version <> excluded.version OR
+			languages.extension <> excluded.extension OR COALESCE(languages.compile_cmd, '') <> excluded.compile_cmd OR
+			languages.execute_cmd <> excluded.execute_cmd OR languages.docker_image <> excluded.docker_image OR
+			languages.is_compiled <> excluded.is_compiled OR languages.is_enabled <> excluded.is_enabled OR
+			languages.smoke_test_code <> excluded.smoke_test_code OR languages.smoke_test_output <> excluded.smoke_test_output
+		)
+		`
+		_, err := db.conn.Exec(query,
+			lang.ID, lang.Name, lang.DisplayName, lang.Version,
+			lang.Extension, lang.CompileCmd, lang.ExecuteCmd,
+			lang.DockerImage, lang.IsCompiled, lang.IsEnabled,
+			lang.SmokeTestCode, lang.SmokeTestOutput, models.LanguageManagedBySeed, time.Now(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to seed language %s: %w", lang.Name, err)
+		}
+
+		// Registrar la revisión vigente si todavía no está en el historial
+		current, err := db.GetLanguageAnyState(lang.ID)
+		if err != nil {
+			return fmt.Errorf("failed to seed language %s: %w", lang.Name, err)
+		}
+		if err := insertLanguageRevision(db.conn, current, &models.LanguageRevision{Action: models.LanguageActionSeed}, true); err != nil {
+			return fmt.Errorf("failed to seed language %s: %w", lang.Name, err)
+		}
+	}
+
+	log.Println("Languages seeded successfully")
+	return nil
+}
+
+// languageColumns son las columnas que lee scanLanguage
+const languageColumns = `id, name, display_name, version, extension, compile_cmd,
+	       execute_cmd, docker_image, is_compiled, is_enabled,
+	       smoke_test_code, smoke_test_output, revision, managed_by, updated_at`
+
+func scanLanguage(row rowScanner) (*models.Language, error) {
+	var lang models.Language
+	var compileCmd sql.NullString
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&lang.ID, &lang.Name, &lang.DisplayName, &lang.Version,
+		&lang.Extension, &compileCmd, &lang.ExecuteCmd,
+		&lang.DockerImage, &lang.IsCompiled, &lang.IsEnabled,
+		&lang.SmokeTestCode, &lang.SmokeTestOutput, &lang.Revision, &lang.ManagedBy, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lang.CompileCmd = compileCmd.String
+	if updatedAt.Valid {
+		lang.UpdatedAt = &updatedAt.Time
+	}
+	return &lang, nil
+}
+
+// GetLanguage obtiene un lenguaje habilitado por ID
+func (db *DB) GetLanguage(id int) (*models.Language, error) {
+	query := `SELECT ` + languageColumns + ` FROM languages WHERE id = $1 AND is_enabled = true`
+
+	lang, err := scanLanguage(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("language not found or disabled")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language: %w", err)
+	}
+	return lang, nil
+}
+
+// GetLanguageAnyState obtiene un lenguaje por ID, esté habilitado o no
+func (db *DB) GetLanguageAnyState(id int) (*models.Language, error) {
+	query := `SELECT ` + languageColumns + ` FROM languages WHERE id = $1`
+
+	lang, err := scanLanguage(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("language not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language: %w", err)
+	}
+	return lang, nil
+}
+
+// GetAllLanguages obtiene todos los lenguajes habilitados
+func (db *DB) GetAllLanguages() ([]models.Language, error) {
+	return db.listLanguages(`SELECT ` + languageColumns + ` FROM languages WHERE is_enabled = true ORDER BY id`)
+}
+
+// ListAllLanguages obtiene todos los lenguajes, incluidos los deshabilitados
+func (db *DB) ListAllLanguages() ([]models.Language, error) {
+	return db.listLanguages(`SELECT ` + languageColumns + ` FROM languages ORDER BY id`)
+}
+
+func (db *DB) listLanguages(query string) ([]models.Language, error) {
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get languages: %w", err)
+	}
+	defer rows.Close()
+
+	languages := []models.Language{}
+	for rows.Next() {
+		lang, err := scanLanguage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan language: %w", err)
+		}
+		languages = append(languages, *lang)
+	}
+	return languages, rows.Err()
+}
+
+// CreateLanguage guarda un lenguaje nuevo (revisión 1) y su primera entrada en el historial
+func (db *DB) CreateLanguage(lang *models.Language, rev *models.LanguageRevision) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	lang.Revision = 1
+	lang.ManagedBy = models.LanguageManagedByAdmin
+	lang.UpdatedAt = &now
+
+	query := `
+	INSERT INTO languages (id, name, display_name, version, extension, compile_cmd, execute_cmd, docker_image,
+	                       is_compiled, is_enabled, smoke_test_code, smoke_test_output, revision, managed_by, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err = tx.Exec(query,
+		lang.ID, lang.Name, lang.DisplayName, lang.Version, lang.Extension, lang.CompileCmd, lang.ExecuteCmd,
+		lang.DockerImage, lang.IsCompiled, lang.IsEnabled, lang.SmokeTestCode, lang.SmokeTestOutput,
+		lang.Revision, lang.ManagedBy, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create language: %w", err)
+	}
+	if err := insertLanguageRevision(tx, lang, rev, false); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit language: %w", err)
+	}
+	return nil
+}
+
+// UpdateLanguage guarda lang como una revisión nueva si sigue en la revisión
+// lang.Revision; si no, retorna ErrLanguageChanged. Desde ese momento el seed
+// ya no lo modifica.
+func (db *DB) UpdateLanguage(lang *models.Language, rev *models.LanguageRevision) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+	UPDATE languages
+	SET display_name = $1, version = $2, extension = $3, compile_cmd = $4, execute_cmd = $5,
+	    docker_image = $6, is_compiled = $7, is_enabled = $8, smoke_test_code = $9, smoke_test_output = $10,
+	    revision = revision + 1, managed_by = $11, updated_at = $12
+	WHERE id = $13 AND revision = $14
+	RETURNING revision
+	`
+	var revision int
+	err = tx.QueryRow(query,
+		lang.DisplayName, lang.Version, lang.Extension, lang.CompileCmd, lang.ExecuteCmd,
+		lang.DockerImage, lang.IsCompiled, lang.IsEnabled, lang.SmokeTestCode, lang.SmokeTestOutput,
+		models.LanguageManagedByAdmin, now, lang.ID, lang.Revision,
+	).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return ErrLanguageChanged
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update language: %w", err)
+	}
+
+	lang.Revision = revision
+	lang.ManagedBy = models.LanguageManagedByAdmin
+	lang.UpdatedAt = &now
+	if err := insertLanguageRevision(tx, lang, rev, false); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit language: %w", err)
+	}
+	return nil
+}
+
+// execer es implementado por *sql.DB y *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertLanguageRevision guarda en el historial una copia de lang en su revisión actual.
+// Con ifMissing no falla si esa revisión ya estaba registrada.
+func insertLanguageRevision(conn execer, lang *models.Language, rev *models.LanguageRevision, ifMissing bool) error {
+	snapshot, err := json.Marshal(lang)
+	if err != nil {
+		return fmt.Errorf("failed to encode language: %w", err)
+	}
+	var smokeTest interface{}
+	if rev.SmokeTest != nil {
+		encoded, err := json.Marshal(rev.SmokeTest)
+		if err != nil {
+			return fmt.Errorf("failed to encode smoke test: %w", err)
+		}
+		smokeTest = string(encoded)
+	}
+
+	query := `
+	INSERT INTO language_revisions (language_id, revision, action, snapshot, changed_by, smoke_test, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if ifMissing {
+		query += ` ON CONFLICT (language_id, revision) DO NOTHING`
+	}
+
+	rev.LanguageID = lang.ID
+	rev.Revision = lang.Revision
+	rev.Language = *lang
+	rev.CreatedAt = time.Now()
+	_, err = conn.Exec(query, rev.LanguageID, rev.Revision, rev.Action, string(snapshot), rev.ChangedBy, smokeTest, rev.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record language revision: %w", err)
+	}
+	return nil
+}
+
+// languageRevisionColumns son las columnas que lee scanLanguageRevision
+const languageRevisionColumns = `id, language_id, revision, action, snapshot, changed_by, smoke_test, created_at`
+
+func scanLanguageRevision(row rowScanner) (*models.LanguageRevision, error) {
+	var rev models.LanguageRevision
+	var snapshot string
+	var smokeTest sql.NullString
+
+	err := row.Scan(&rev.ID, &rev.LanguageID, &rev.Revision, &rev.Action, &snapshot, &rev.ChangedBy, &smokeTest, &rev.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(snapshot), &rev.Language); err != nil {
+		return nil, fmt.Errorf("failed to decode language snapshot: %w", err)
+	}
+	if smokeTest.Valid {
+		rev.SmokeTest = &models.SmokeTestResult{}
+		if err := json.Unmarshal([]byte(smokeTest.String), rev.SmokeTest); err != nil {
+			return nil, fmt.Errorf("failed to decode smoke test: %w", err)
+		}
+	}
+	return &rev, nil
+}
+
+// ListLanguageRevisions retorna el historial de un lenguaje, la revisión más reciente primero
+func (db *DB) ListLanguageRevisions(languageID int) ([]models.LanguageRevision, error) {
+	query := `SELECT ` + languageRevisionColumns + ` FROM language_revisions WHERE language_id = $1 ORDER BY revision DESC`
+	rows, err := db.conn.Query(query, languageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list language revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.LanguageRevision{}
+	for rows.Next() {
+		rev, err := scanLanguageRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan language revision: %w", err)
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetLanguageRevision obtiene una revisión del historial de un lenguaje
+func (db *DB) GetLanguageRevision(languageID, revision int) (*models.LanguageRevision, error) {
+	query := `SELECT ` + languageRevisionColumns + ` FROM language_revisions WHERE language_id = $1 AND revision = $2`
+
+	rev, err := scanLanguageRevision(db.conn.QueryRow(query, languageID, revision))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("language revision not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language revision: %w", err)
+	}
+	return rev, nil
+}
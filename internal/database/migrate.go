@@ -0,0 +1,276 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Las migraciones viven en migrations/<motor>/NNNN_nombre.{up,down}.sql y se compilan en el binario
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID identifica el advisory lock de PostgreSQL que serializa las
+// migraciones cuando arrancan varias instancias a la vez ("roju" en ASCII)
+const migrationLockID int64 = 0x726f6a75
+
+// Migration es un cambio de esquema numerado
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState es una migración y, si se aplicó, cuándo
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// loadMigrations lee las migraciones embebidas de dir ordenadas por versión
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		filename := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(filename, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(filename, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name %q", filename)
+		}
+
+		base := strings.TrimSuffix(filename, "."+direction+".sql")
+		number, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", filename)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, filename))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", filename, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// withMigrationLock ejecuta fn con una conexión que tiene el lock de migraciones.
+// En PostgreSQL es un advisory lock de sesión, así que todo debe usar la misma conexión.
+func (db *DB) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	unlock, err := db.dialect.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedMigrations retorna las versiones aplicadas y cuándo
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// runMigration aplica (o revierte) una migración y actualiza schema_migrations
+// en la misma transacción
+func runMigration(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	script := migration.Up
+	if !up {
+		script = migration.Down
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+// Migrate aplica todas las migraciones pendientes en orden y retorna cuántas aplicó
+func (db *DB) Migrate(ctx context.Context) (int, error) {
+	migrations, err := loadMigrations(db.dialect.migrationsDir())
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = db.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			log.Printf("Migration applied: %04d_%s", migration.Version, migration.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateDown revierte las últimas steps migraciones aplicadas y retorna cuántas revirtió
+func (db *DB) MigrateDown(ctx context.Context, steps int) (int, error) {
+	migrations, err := loadMigrations(db.dialect.migrationsDir())
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = db.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %04d_%s is not reversible", migration.Version, migration.Name)
+			}
+			if err := runMigration(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			log.Printf("Migration reverted: %04d_%s", migration.Version, migration.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrationStatus retorna todas las migraciones conocidas y si están aplicadas
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := loadMigrations(db.dialect.migrationsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	err = db.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		states = make([]MigrationState, len(migrations))
+		for i, migration := range migrations {
+			states[i] = MigrationState{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				states[i].AppliedAt = &appliedAt
+			}
+		}
+		return nil
+	})
+	return states, err
+}
+
+// PendingMigrations retorna cuántas migraciones faltan por aplicar
+func (db *DB) PendingMigrations(ctx context.Context) (int, error) {
+	states, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, state := range states {
+		if state.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
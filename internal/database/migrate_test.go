@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	states, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	if len(states) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for _, state := range states {
+		if state.AppliedAt == nil {
+			t.Fatalf("migration %+v not applied", state)
+		}
+	}
+
+	// Aplicar de nuevo no hace nada
+	applied, err := db.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if applied != 0 {
+		t.Fatalf("expected no migrations on an up-to-date schema, applied %d", applied)
+	}
+
+	reverted, err := db.MigrateDown(ctx, len(states))
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if reverted != len(states) {
+		t.Fatalf("reverted %d migrations, want %d", reverted, len(states))
+	}
+	pending, err := db.PendingMigrations(ctx)
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if pending != len(states) {
+		t.Fatalf("pending = %d after reverting everything, want %d", pending, len(states))
+	}
+
+	// Las migraciones down dejan el esquema listo para volver a subir
+	applied, err = db.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate after down: %v", err)
+	}
+	if applied != len(states) {
+		t.Fatalf("applied %d migrations, want %d", applied, len(states))
+	}
+	if pending, _ := db.PendingMigrations(ctx); pending != 0 {
+		t.Fatalf("pending = %d after migrating up, want 0", pending)
+	}
+}
+
+func TestMigrateDownOneStep(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	reverted, err := db.MigrateDown(ctx, 1)
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if reverted != 1 {
+		t.Fatalf("reverted %d migrations, want 1", reverted)
+	}
+	if pending, _ := db.PendingMigrations(ctx); pending != 1 {
+		t.Fatalf("pending = %d, want 1", pending)
+	}
+}
@@ -0,0 +1,38 @@
+-- Esquema original: lenguajes, submissions y logs de webhooks.
+-- IF NOT EXISTS permite adoptar bases creadas por InitSchema.
+CREATE TABLE IF NOT EXISTS languages (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(50) NOT NULL UNIQUE,
+	display_name VARCHAR(100) NOT NULL,
+	version VARCHAR(50) NOT NULL,
+	extension VARCHAR(10) NOT NULL,
+	compile_cmd TEXT,
+	execute_cmd TEXT NOT NULL,
+	docker_image VARCHAR(200) NOT NULL,
+	is_compiled BOOLEAN DEFAULT FALSE,
+	is_enabled BOOLEAN DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS submissions (
+	id VARCHAR(36) PRIMARY KEY,
+	language_id INTEGER NOT NULL REFERENCES languages(id),
+	source_code TEXT NOT NULL,
+	stdin TEXT,
+	expected_output TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'queued',
+	stdout TEXT,
+	stderr TEXT,
+	exit_code INTEGER DEFAULT -1,
+	time DOUBLE PRECISION DEFAULT 0,
+	memory INTEGER DEFAULT 0,
+	compile_output TEXT,
+	message TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	finished_at TIMESTAMP,
+	CONSTRAINT fk_language FOREIGN KEY (language_id) REFERENCES languages(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_submissions_status ON submissions(status);
+CREATE INDEX IF NOT EXISTS idx_submissions_created_at ON submissions(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_submissions_language ON submissions(language_id);
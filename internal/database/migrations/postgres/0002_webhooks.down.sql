@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webhook_logs;
+ALTER TABLE submissions DROP COLUMN IF EXISTS webhook_url;
@@ -0,0 +1,17 @@
+-- Las bases creadas antes de los webhooks no tienen la columna
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS webhook_url TEXT;
+
+CREATE TABLE IF NOT EXISTS webhook_logs (
+	id SERIAL PRIMARY KEY,
+	submission_id VARCHAR(36) NOT NULL,
+	webhook_url TEXT NOT NULL,
+	attempt INTEGER NOT NULL DEFAULT 1,
+	status_code INTEGER,
+	response_body TEXT,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT fk_webhook_submission FOREIGN KEY (submission_id) REFERENCES submissions(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_logs_submission ON webhook_logs(submission_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_logs_created_at ON webhook_logs(created_at DESC);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_submissions_api_key;
+DROP INDEX IF EXISTS idx_submissions_user;
+DROP INDEX IF EXISTS idx_submissions_problem;
+
+ALTER TABLE submissions DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE submissions DROP COLUMN IF EXISTS user_id;
+ALTER TABLE submissions DROP COLUMN IF EXISTS problem_id;
+ALTER TABLE submissions DROP COLUMN IF EXISTS scheduled_at;
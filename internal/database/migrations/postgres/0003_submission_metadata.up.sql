@@ -0,0 +1,9 @@
+-- Ejecución diferida y dueños de las submissions
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP;
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS problem_id VARCHAR(100);
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS user_id VARCHAR(255);
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS api_key_id VARCHAR(36);
+
+CREATE INDEX IF NOT EXISTS idx_submissions_problem ON submissions(problem_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_submissions_user ON submissions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_submissions_api_key ON submissions(api_key_id, created_at DESC);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	id VARCHAR(36) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	key_prefix VARCHAR(16) NOT NULL,
+	key_hash VARCHAR(64) NOT NULL UNIQUE,
+	scopes TEXT[] NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+
+-- Plan de rate limiting y cuotas ('' = default)
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS plan VARCHAR(50) NOT NULL DEFAULT '';
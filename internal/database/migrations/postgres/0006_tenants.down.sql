@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_submissions_tenant;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE submissions DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
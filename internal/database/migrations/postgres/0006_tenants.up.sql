@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tenants (
+	id VARCHAR(64) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	enabled_languages INTEGER[] NOT NULL DEFAULT '{}',
+	max_time_limit DOUBLE PRECISION NOT NULL DEFAULT 0,
+	max_memory_mb INTEGER NOT NULL DEFAULT 0,
+	max_cpus DOUBLE PRECISION NOT NULL DEFAULT 0,
+	webhook_secret TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_submissions_tenant ON submissions(tenant_id, created_at DESC);
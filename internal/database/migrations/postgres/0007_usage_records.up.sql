@@ -0,0 +1,17 @@
+-- Ledger de consumo por submission para facturación
+CREATE TABLE IF NOT EXISTS usage_records (
+	submission_id VARCHAR(36) PRIMARY KEY,
+	tenant_id VARCHAR(64) NOT NULL DEFAULT '',
+	api_key_id VARCHAR(36) NOT NULL DEFAULT '',
+	user_id VARCHAR(255) NOT NULL DEFAULT '',
+	language_id INTEGER NOT NULL,
+	language_name VARCHAR(50) NOT NULL DEFAULT '',
+	cpu_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+	wall_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+	memory_mb_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+	compile_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+	recorded_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_recorded_at ON usage_records(recorded_at);
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant ON usage_records(tenant_id, recorded_at);
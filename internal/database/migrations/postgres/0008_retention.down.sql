@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS retention_runs;
+ALTER TABLE submissions DROP COLUMN IF EXISTS payload_purged_at;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS language_revisions;
+ALTER TABLE languages DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE languages DROP COLUMN IF EXISTS managed_by;
+ALTER TABLE languages DROP COLUMN IF EXISTS revision;
+ALTER TABLE languages DROP COLUMN IF EXISTS smoke_test_output;
+ALTER TABLE languages DROP COLUMN IF EXISTS smoke_test_code;
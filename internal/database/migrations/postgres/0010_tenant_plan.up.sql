@@ -0,0 +1,2 @@
+-- Plan de cuotas del tenant: sus contadores son compartidos, el plan también
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS plan VARCHAR(50) NOT NULL DEFAULT '';
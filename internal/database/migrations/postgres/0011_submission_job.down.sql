@@ -0,0 +1,2 @@
+ALTER TABLE submissions DROP COLUMN IF EXISTS quota_key;
+ALTER TABLE submissions DROP COLUMN IF EXISTS priority;
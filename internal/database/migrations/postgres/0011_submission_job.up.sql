@@ -0,0 +1,3 @@
+-- Prioridad y clave de cuota del job: para reencolar una submission sin perderlas
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS quota_key VARCHAR(255);
@@ -0,0 +1,2 @@
+DROP TABLE webhook_logs;
+ALTER TABLE submissions DROP COLUMN webhook_url;
@@ -0,0 +1,15 @@
+ALTER TABLE submissions ADD COLUMN webhook_url TEXT;
+
+CREATE TABLE webhook_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	submission_id VARCHAR(36) NOT NULL REFERENCES submissions(id),
+	webhook_url TEXT NOT NULL,
+	attempt INTEGER NOT NULL DEFAULT 1,
+	status_code INTEGER,
+	response_body TEXT,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_logs_submission ON webhook_logs(submission_id);
+CREATE INDEX idx_webhook_logs_created_at ON webhook_logs(created_at DESC);
@@ -0,0 +1,8 @@
+DROP INDEX idx_submissions_api_key;
+DROP INDEX idx_submissions_user;
+DROP INDEX idx_submissions_problem;
+
+ALTER TABLE submissions DROP COLUMN api_key_id;
+ALTER TABLE submissions DROP COLUMN user_id;
+ALTER TABLE submissions DROP COLUMN problem_id;
+ALTER TABLE submissions DROP COLUMN scheduled_at;
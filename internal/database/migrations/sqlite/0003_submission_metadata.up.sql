@@ -0,0 +1,8 @@
+ALTER TABLE submissions ADD COLUMN scheduled_at TIMESTAMP;
+ALTER TABLE submissions ADD COLUMN problem_id VARCHAR(100);
+ALTER TABLE submissions ADD COLUMN user_id VARCHAR(255);
+ALTER TABLE submissions ADD COLUMN api_key_id VARCHAR(36);
+
+CREATE INDEX idx_submissions_problem ON submissions(problem_id, created_at DESC);
+CREATE INDEX idx_submissions_user ON submissions(user_id, created_at DESC);
+CREATE INDEX idx_submissions_api_key ON submissions(api_key_id, created_at DESC);
@@ -0,0 +1,8 @@
+CREATE TABLE idempotency_keys (
+	key VARCHAR(255) PRIMARY KEY,
+	fingerprint VARCHAR(64) NOT NULL,
+	submission_id VARCHAR(36) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
@@ -0,0 +1,12 @@
+-- scopes es una lista JSON
+CREATE TABLE api_keys (
+	id VARCHAR(36) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	key_prefix VARCHAR(16) NOT NULL,
+	key_hash VARCHAR(64) NOT NULL UNIQUE,
+	scopes TEXT NOT NULL DEFAULT '[]',
+	plan VARCHAR(50) NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
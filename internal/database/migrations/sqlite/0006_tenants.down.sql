@@ -0,0 +1,6 @@
+DROP INDEX idx_submissions_tenant;
+
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE submissions DROP COLUMN tenant_id;
+
+DROP TABLE tenants;
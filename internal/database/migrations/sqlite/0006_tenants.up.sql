@@ -0,0 +1,17 @@
+-- enabled_languages es una lista JSON
+CREATE TABLE tenants (
+	id VARCHAR(64) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	enabled_languages TEXT NOT NULL DEFAULT '[]',
+	max_time_limit DOUBLE PRECISION NOT NULL DEFAULT 0,
+	max_memory_mb INTEGER NOT NULL DEFAULT 0,
+	max_cpus DOUBLE PRECISION NOT NULL DEFAULT 0,
+	webhook_secret TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE submissions ADD COLUMN tenant_id VARCHAR(64);
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64);
+
+CREATE INDEX idx_submissions_tenant ON submissions(tenant_id, created_at DESC);
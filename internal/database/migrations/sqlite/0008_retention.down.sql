@@ -0,0 +1,2 @@
+DROP TABLE retention_runs;
+ALTER TABLE submissions DROP COLUMN payload_purged_at;
@@ -0,0 +1,20 @@
+-- Retención: marca de payloads purgados e historial de ejecuciones del janitor
+ALTER TABLE submissions ADD COLUMN payload_purged_at TIMESTAMP;
+
+CREATE TABLE retention_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	triggered_by VARCHAR(20) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	payloads_purged INTEGER NOT NULL DEFAULT 0,
+	submissions_deleted INTEGER NOT NULL DEFAULT 0,
+	webhook_logs_deleted INTEGER NOT NULL DEFAULT 0,
+	archived INTEGER NOT NULL DEFAULT 0,
+	archive_file TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	started_at TIMESTAMP NOT NULL,
+	finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_retention_runs_started_at ON retention_runs(started_at DESC);
+-- Solo una ejecución en curso a la vez
+CREATE UNIQUE INDEX idx_retention_runs_running ON retention_runs(status) WHERE status = 'running';
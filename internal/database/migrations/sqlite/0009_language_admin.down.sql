@@ -0,0 +1,6 @@
+DROP TABLE language_revisions;
+ALTER TABLE languages DROP COLUMN updated_at;
+ALTER TABLE languages DROP COLUMN managed_by;
+ALTER TABLE languages DROP COLUMN revision;
+ALTER TABLE languages DROP COLUMN smoke_test_output;
+ALTER TABLE languages DROP COLUMN smoke_test_code;
@@ -0,0 +1,19 @@
+-- Administración de lenguajes: smoke test, revisiones e historial de cambios
+ALTER TABLE languages ADD COLUMN smoke_test_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE languages ADD COLUMN smoke_test_output TEXT NOT NULL DEFAULT '';
+ALTER TABLE languages ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+-- seed = SeedLanguages lo mantiene al día; admin = lo cambió un admin y el seed ya no lo toca
+ALTER TABLE languages ADD COLUMN managed_by VARCHAR(10) NOT NULL DEFAULT 'seed';
+ALTER TABLE languages ADD COLUMN updated_at TIMESTAMP;
+
+CREATE TABLE language_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	language_id INTEGER NOT NULL REFERENCES languages(id),
+	revision INTEGER NOT NULL,
+	action VARCHAR(20) NOT NULL,
+	snapshot TEXT NOT NULL,
+	changed_by VARCHAR(100) NOT NULL DEFAULT '',
+	smoke_test TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (language_id, revision)
+);
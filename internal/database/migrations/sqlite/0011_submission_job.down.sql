@@ -0,0 +1,2 @@
+ALTER TABLE submissions DROP COLUMN quota_key;
+ALTER TABLE submissions DROP COLUMN priority;
@@ -0,0 +1,3 @@
+-- Prioridad y clave de cuota del job: para reencolar una submission sin perderlas
+ALTER TABLE submissions ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE submissions ADD COLUMN quota_key VARCHAR(255);
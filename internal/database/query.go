@@ -0,0 +1,355 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// SubmissionQuery describe un listado de submissions con filtros y paginación por cursor
+type SubmissionQuery struct {
+	Status        string
+	LanguageID    int
+	ProblemID     string
+	UserID        string
+	APIKeyID      string // solo las creadas con esta API key
+	TenantID      string // solo las del tenant
+	Own           bool   // como SubmissionScope.Own
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	After     *SubmissionCursor // continuar después de esta submission
+	Ascending bool              // por defecto, las más recientes primero
+	Limit     int
+	Fields    []string // nombres JSON de las columnas a leer; vacío = todas
+}
+
+// SubmissionScope limita las submissions visibles para un cliente ("" = sin límite)
+type SubmissionScope struct {
+	TenantID string
+	APIKeyID string
+	UserID   string
+	// Own limita a las propias (read-own): el tenant debe coincidir exactamente
+	// ("" = solo las globales) y, por usuario, solo cuentan las creadas con su
+	// JWT, no las que una API key atribuyó a ese user_id
+	Own bool
+}
+
+// conditions arma el WHERE del scope con placeholders desde $1
+func (s SubmissionScope) conditions() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1))
+	}
+
+	if s.Own {
+		add("COALESCE(tenant_id, '') = ?", s.TenantID)
+		if s.UserID != "" {
+			conditions = append(conditions, "api_key_id IS NULL")
+		}
+	} else if s.TenantID != "" {
+		add("tenant_id = ?", s.TenantID)
+	}
+	if s.APIKeyID != "" {
+		add("api_key_id = ?", s.APIKeyID)
+	}
+	if s.UserID != "" {
+		add("user_id = ?", s.UserID)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// SubmissionCursor marca la posición de la última submission de una página
+type SubmissionCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode serializa el cursor en un token opaco para el cliente
+func (c SubmissionCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeSubmissionCursor lee un cursor generado con Encode
+func DecodeSubmissionCursor(token string) (*SubmissionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &SubmissionCursor{CreatedAt: createdAt, ID: parts[1]}, nil
+}
+
+// submissionFieldColumns relaciona los nombres JSON con las columnas de submissions
+var submissionFieldColumns = map[string]string{
+	"id":                "id",
+	"language_id":       "language_id",
+	"source_code":       "source_code",
+	"stdin":             "stdin",
+	"expected_output":   "expected_output",
+	"status":            "status",
+	"stdout":            "stdout",
+	"stderr":            "stderr",
+	"exit_code":         "exit_code",
+	"time":              "time",
+	"memory":            "memory",
+	"compile_output":    "compile_output",
+	"message":           "message",
+	"webhook_url":       "webhook_url",
+	"created_at":        "created_at",
+	"finished_at":       "finished_at",
+	"scheduled_at":      "scheduled_at",
+	"problem_id":        "problem_id",
+	"user_id":           "user_id",
+	"api_key_id":        "api_key_id",
+	"tenant_id":         "tenant_id",
+	"payload_purged_at": "payload_purged_at",
+}
+
+// IsSubmissionField indica si name es un campo válido para SubmissionQuery.Fields
+func IsSubmissionField(name string) bool {
+	_, ok := submissionFieldColumns[name]
+	return ok
+}
+
+// QuerySubmissions lista submissions según q. Retorna hasta q.Limit submissions y el
+// cursor para la página siguiente (nil si no hay más).
+func (db *DB) QuerySubmissions(q SubmissionQuery) ([]models.Submission, *SubmissionCursor, error) {
+	fields := selectedFields(q.Fields)
+	query, args := buildSubmissionQuery(db.dialect, q, fields)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := []models.Submission{}
+	for rows.Next() {
+		sub, err := scanSubmissionFields(rows, fields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan submission: %w", err)
+		}
+		submissions = append(submissions, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to query submissions: %w", err)
+	}
+
+	// Se pidió una fila extra para saber si hay otra página
+	var next *SubmissionCursor
+	if len(submissions) > q.Limit {
+		submissions = submissions[:q.Limit]
+		last := submissions[len(submissions)-1]
+		next = &SubmissionCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	if err := db.loadAllBlobs(submissions); err != nil {
+		return nil, nil, err
+	}
+
+	return submissions, next, nil
+}
+
+// selectedFields retorna las columnas a leer; id y created_at siempre se leen para el cursor
+func selectedFields(requested []string) []string {
+	if len(requested) == 0 {
+		return []string{
+			"id", "language_id", "source_code", "stdin", "expected_output", "status",
+			"stdout", "stderr", "exit_code", "time", "memory", "compile_output", "message",
+			"webhook_url", "created_at", "finished_at", "scheduled_at", "problem_id", "user_id", "api_key_id",
+			"tenant_id", "payload_purged_at",
+		}
+	}
+
+	fields := []string{"id", "created_at"}
+	for _, field := range requested {
+		if field != "id" && field != "created_at" && IsSubmissionField(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// buildSubmissionQuery arma el SELECT con filtros, orden y paginación por keyset
+func buildSubmissionQuery(d dialect, q SubmissionQuery, fields []string) (string, []interface{}) {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = submissionFieldColumns[field]
+	}
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, values ...interface{}) {
+		for _, value := range values {
+			args = append(args, value)
+			condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if q.Status != "" {
+		add("status = ?", q.Status)
+	}
+	if q.LanguageID != 0 {
+		add("language_id = ?", q.LanguageID)
+	}
+	if q.ProblemID != "" {
+		add("problem_id = ?", q.ProblemID)
+	}
+	if q.UserID != "" {
+		add("user_id = ?", q.UserID)
+	}
+	if q.APIKeyID != "" {
+		add("api_key_id = ?", q.APIKeyID)
+	}
+	if q.Own {
+		add("COALESCE(tenant_id, '') = ?", q.TenantID)
+		if q.UserID != "" {
+			add("api_key_id IS NULL")
+		}
+	} else if q.TenantID != "" {
+		add("tenant_id = ?", q.TenantID)
+	}
+	if q.CreatedAfter != nil {
+		after, value := d.timestamp(q.CreatedAfter.UTC())
+		add("created_at >= "+after, value)
+	}
+	if q.CreatedBefore != nil {
+		before, value := d.timestamp(q.CreatedBefore.UTC())
+		add("created_at < "+before, value)
+	}
+
+	order := "DESC"
+	comparison := "<"
+	if q.Ascending {
+		order = "ASC"
+		comparison = ">"
+	}
+	if q.After != nil {
+		after, value := d.timestamp(q.After.CreatedAt.UTC())
+		add("(created_at, id) "+comparison+" ("+after+", ?)", value, q.After.ID)
+	}
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM submissions"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT %d", order, order, q.Limit+1)
+
+	return query, args
+}
+
+// scanSubmissionFields lee una fila con las columnas de fields
+func scanSubmissionFields(row rowScanner, fields []string) (*models.Submission, error) {
+	var sub models.Submission
+	dests := make([]interface{}, len(fields))
+	assigns := make([]func(), 0, len(fields))
+
+	for i, field := range fields {
+		dest, assign := submissionFieldTarget(&sub, field)
+		dests[i] = dest
+		if assign != nil {
+			assigns = append(assigns, assign)
+		}
+	}
+
+	if err := row.Scan(dests...); err != nil {
+		return nil, err
+	}
+	for _, assign := range assigns {
+		assign()
+	}
+	return &sub, nil
+}
+
+// submissionFieldTarget retorna dónde escanear un campo y, para columnas NULL o
+// codificadas, la función que lo copia a la submission
+func submissionFieldTarget(sub *models.Submission, field string) (interface{}, func()) {
+	text := func(target *string) (interface{}, func()) {
+		var value sql.NullString
+		return &value, func() { *target = decodeText(value.String) }
+	}
+	timestamp := func(target **time.Time) (interface{}, func()) {
+		var value sql.NullTime
+		return &value, func() {
+			if value.Valid {
+				*target = &value.Time
+			}
+		}
+	}
+
+	switch field {
+	case "id":
+		return &sub.ID, nil
+	case "language_id":
+		return &sub.LanguageID, nil
+	case "status":
+		return &sub.Status, nil
+	case "exit_code":
+		return &sub.ExitCode, nil
+	case "time":
+		return &sub.Time, nil
+	case "memory":
+		return &sub.Memory, nil
+	case "created_at":
+		return &sub.CreatedAt, nil
+	case "source_code":
+		return text(&sub.SourceCode)
+	case "stdin":
+		return text(&sub.Stdin)
+	case "expected_output":
+		return text(&sub.ExpectedOut)
+	case "stdout":
+		return text(&sub.Stdout)
+	case "stderr":
+		return text(&sub.Stderr)
+	case "compile_output":
+		return text(&sub.CompileOut)
+	case "message":
+		return text(&sub.Message)
+	case "webhook_url":
+		return text(&sub.WebhookURL)
+	case "problem_id":
+		return text(&sub.ProblemID)
+	case "user_id":
+		return text(&sub.UserID)
+	case "api_key_id":
+		return text(&sub.APIKeyID)
+	case "tenant_id":
+		return text(&sub.TenantID)
+	case "finished_at":
+		return timestamp(&sub.FinishedAt)
+	case "scheduled_at":
+		return timestamp(&sub.ScheduledAt)
+	case "payload_purged_at":
+		return timestamp(&sub.PayloadPurgedAt)
+	}
+
+	var ignored interface{}
+	return &ignored, nil
+}
+
+// nullString guarda los textos vacíos como NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// collectPages recorre todas las páginas de q y retorna los IDs en orden
+func collectPages(t *testing.T, db *DB, q SubmissionQuery) []string {
+	t.Helper()
+
+	var ids []string
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		submissions, next, err := db.QuerySubmissions(q)
+		if err != nil {
+			t.Fatalf("QuerySubmissions: %v", err)
+		}
+		if len(submissions) > q.Limit {
+			t.Fatalf("page has %d submissions, limit %d", len(submissions), q.Limit)
+		}
+		for _, sub := range submissions {
+			ids = append(ids, sub.ID)
+		}
+		if next == nil {
+			return ids
+		}
+
+		// El cursor viaja al cliente como token opaco
+		cursor, err := DecodeSubmissionCursor(next.Encode())
+		if err != nil {
+			t.Fatalf("DecodeSubmissionCursor: %v", err)
+		}
+		q.After = cursor
+	}
+}
+
+func TestQuerySubmissionsKeysetPaging(t *testing.T) {
+	db := newTestDB(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newTestSubmission(t, db, "a", base)
+	newTestSubmission(t, db, "b", base.Add(time.Minute))
+	// Mismo created_at: el ID desempata
+	newTestSubmission(t, db, "c1", base.Add(2*time.Minute))
+	newTestSubmission(t, db, "c2", base.Add(2*time.Minute))
+	newTestSubmission(t, db, "d", base.Add(3*time.Minute))
+
+	desc := collectPages(t, db, SubmissionQuery{Limit: 2})
+	if want := []string{"d", "c2", "c1", "b", "a"}; !reflect.DeepEqual(desc, want) {
+		t.Fatalf("descending pages = %v, want %v", desc, want)
+	}
+
+	asc := collectPages(t, db, SubmissionQuery{Limit: 2, Ascending: true})
+	if want := []string{"a", "b", "c1", "c2", "d"}; !reflect.DeepEqual(asc, want) {
+		t.Fatalf("ascending pages = %v, want %v", asc, want)
+	}
+
+	// Una página exacta no deja cursor
+	if _, next, err := db.QuerySubmissions(SubmissionQuery{Limit: 5}); err != nil || next != nil {
+		t.Fatalf("expected no cursor for a full page, got %+v (err %v)", next, err)
+	}
+}
+
+func TestQuerySubmissionsCreatedRange(t *testing.T) {
+	db := newTestDB(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newTestSubmission(t, db, "early", base)
+	newTestSubmission(t, db, "late", base.Add(time.Hour))
+
+	// Un filtro con otra zona horaria se compara por instante
+	zone := time.FixedZone("UTC-6", -6*3600)
+	after := base.Add(30 * time.Minute).In(zone)
+	submissions, _, err := db.QuerySubmissions(SubmissionQuery{Limit: 10, CreatedAfter: &after})
+	if err != nil {
+		t.Fatalf("QuerySubmissions: %v", err)
+	}
+	if len(submissions) != 1 || submissions[0].ID != "late" {
+		t.Fatalf("created_after filter returned %+v, want only late", submissions)
+	}
+
+	before := base.Add(30 * time.Minute).In(zone)
+	submissions, _, err = db.QuerySubmissions(SubmissionQuery{Limit: 10, CreatedBefore: &before})
+	if err != nil {
+		t.Fatalf("QuerySubmissions: %v", err)
+	}
+	if len(submissions) != 1 || submissions[0].ID != "early" {
+		t.Fatalf("created_before filter returned %+v, want only early", submissions)
+	}
+}
@@ -0,0 +1,289 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// RetentionScope selecciona las submissions terminadas a las que aplica una
+// regla de retención
+type RetentionScope struct {
+	Status         string   // estado final exacto
+	TenantID       string   // solo este tenant ("" = cualquiera salvo ExcludeTenants)
+	ExcludeTenants []string // tenants que tienen reglas propias
+}
+
+// ExpiredPayloadIDs retorna hasta limit submissions del scope creadas antes de
+// before cuyo código y salidas todavía no se purgaron
+func (db *DB) ExpiredPayloadIDs(scope RetentionScope, before time.Time, limit int) ([]string, error) {
+	return db.expiredIDs(scope, before, limit, "payload_purged_at IS NULL")
+}
+
+// ExpiredSubmissionIDs retorna hasta limit submissions del scope creadas antes de before
+func (db *DB) ExpiredSubmissionIDs(scope RetentionScope, before time.Time, limit int) ([]string, error) {
+	return db.expiredIDs(scope, before, limit, "")
+}
+
+func (db *DB) expiredIDs(scope RetentionScope, before time.Time, limit int, extra string) ([]string, error) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	cutoff, value := db.dialect.timestamp(before.UTC())
+	conditions := []string{
+		"status = " + arg(scope.Status),
+		"created_at < " + strings.Replace(cutoff, "?", arg(value), 1),
+	}
+	if scope.TenantID != "" {
+		conditions = append(conditions, "tenant_id = "+arg(scope.TenantID))
+	} else if len(scope.ExcludeTenants) > 0 {
+		placeholders := make([]string, len(scope.ExcludeTenants))
+		for i, id := range scope.ExcludeTenants {
+			placeholders[i] = arg(id)
+		}
+		conditions = append(conditions, "(tenant_id IS NULL OR tenant_id NOT IN ("+strings.Join(placeholders, ", ")+"))")
+	}
+	if extra != "" {
+		conditions = append(conditions, extra)
+	}
+
+	query := "SELECT id FROM submissions WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY created_at LIMIT " + arg(limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired submissions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan submission id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// idList arma los placeholders de una lista IN a partir de $offset+1
+func idList(ids []string, offset int) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", offset+i+1)
+		args[i] = id
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// PurgeSubmissionPayloads borra el código, la entrada y las salidas de las
+// submissions indicadas, conservando sus metadatos
+func (db *DB) PurgeSubmissionPayloads(ids []string, purgedAt time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	markers, err := db.submissionBlobMarkers(db.conn, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	list, args := idList(ids, 1)
+	query := `
+	UPDATE submissions
+	SET source_code = '', stdin = '', expected_output = '',
+	    stdout = NULL, stderr = NULL, compile_output = NULL, payload_purged_at = $1
+	WHERE id IN (` + list + `) AND payload_purged_at IS NULL
+	`
+	result, err := db.conn.Exec(query, append([]interface{}{purgedAt}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge payloads: %w", err)
+	}
+
+	db.releaseBlobs(markers)
+	return result.RowsAffected()
+}
+
+// DeleteSubmissions borra las submissions indicadas junto con sus logs de webhooks.
+// El ledger de consumo se conserva para facturación.
+func (db *DB) DeleteSubmissions(ids []string) (submissions int64, webhookLogs int64, err error) {
+	if len(ids) == 0 {
+		return 0, 0, nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	markers, err := db.submissionBlobMarkers(tx, ids)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	list, args := idList(ids, 0)
+	result, err := tx.Exec(`DELETE FROM webhook_logs WHERE submission_id IN (`+list+`)`, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete webhook logs: %w", err)
+	}
+	webhookLogs, _ = result.RowsAffected()
+
+	result, err = tx.Exec(`DELETE FROM submissions WHERE id IN (`+list+`)`, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete submissions: %w", err)
+	}
+	submissions, _ = result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit deletion: %w", err)
+	}
+
+	db.releaseBlobs(markers)
+	return submissions, webhookLogs, nil
+}
+
+// GetWebhookLogs retorna los intentos de entrega de las submissions indicadas
+func (db *DB) GetWebhookLogs(submissionIDs []string) ([]models.WebhookLog, error) {
+	if len(submissionIDs) == 0 {
+		return nil, nil
+	}
+
+	list, args := idList(submissionIDs, 0)
+	query := `
+	SELECT id, submission_id, webhook_url, attempt, status_code, response_body, error, created_at
+	FROM webhook_logs
+	WHERE submission_id IN (` + list + `)
+	ORDER BY id
+	`
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []models.WebhookLog
+	for rows.Next() {
+		var entry models.WebhookLog
+		var statusCode sql.NullInt64
+		var responseBody, errorMsg sql.NullString
+		err := rows.Scan(&entry.ID, &entry.SubmissionID, &entry.WebhookURL, &entry.Attempt,
+			&statusCode, &responseBody, &errorMsg, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook log: %w", err)
+		}
+		entry.StatusCode = int(statusCode.Int64)
+		entry.ResponseBody = decodeText(responseBody.String)
+		entry.Error = errorMsg.String
+		logs = append(logs, entry)
+	}
+	return logs, rows.Err()
+}
+
+// retentionRunColumns son las columnas que lee scanRetentionRun
+const retentionRunColumns = `id, triggered_by, status, payloads_purged, submissions_deleted,
+	       webhook_logs_deleted, archived, archive_file, error, started_at, finished_at`
+
+func scanRetentionRun(row rowScanner) (*models.RetentionRun, error) {
+	var run models.RetentionRun
+	var finishedAt sql.NullTime
+	err := row.Scan(&run.ID, &run.TriggeredBy, &run.Status, &run.PayloadsPurged, &run.SubmissionsDeleted,
+		&run.WebhookLogsDeleted, &run.Archived, &run.ArchiveFile, &run.Error, &run.StartedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+// StartRetentionRun registra una ejecución en curso. Retorna false si ya hay
+// otra en curso (un índice único permite una sola a la vez).
+func (db *DB) StartRetentionRun(run *models.RetentionRun) (bool, error) {
+	run.Status = models.RetentionRunning
+	query := `
+	INSERT INTO retention_runs (triggered_by, status, started_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING
+	RETURNING id
+	`
+	err := db.conn.QueryRow(query, run.TriggeredBy, run.Status, run.StartedAt).Scan(&run.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to start retention run: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateRetentionRun guarda el progreso o el resultado de una ejecución
+func (db *DB) UpdateRetentionRun(run *models.RetentionRun) error {
+	query := `
+	UPDATE retention_runs
+	SET status = $1, payloads_purged = $2, submissions_deleted = $3, webhook_logs_deleted = $4,
+	    archived = $5, archive_file = $6, error = $7, finished_at = $8
+	WHERE id = $9
+	`
+	_, err := db.conn.Exec(query, run.Status, run.PayloadsPurged, run.SubmissionsDeleted,
+		run.WebhookLogsDeleted, run.Archived, run.ArchiveFile, run.Error, run.FinishedAt, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to finish retention run: %w", err)
+	}
+	return nil
+}
+
+// AbandonRetentionRuns marca como fallidas las ejecuciones que siguen en curso
+// desde antes de before (el proceso que las corría murió)
+func (db *DB) AbandonRetentionRuns(before time.Time) (int64, error) {
+	query := `
+	UPDATE retention_runs
+	SET status = $1, error = $2, finished_at = $3
+	WHERE status = $4 AND started_at < $5
+	`
+	result, err := db.conn.Exec(query, models.RetentionFailed, "abandoned", time.Now(), models.RetentionRunning, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to abandon retention runs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetRetentionRun obtiene una ejecución por ID
+func (db *DB) GetRetentionRun(id int64) (*models.RetentionRun, error) {
+	query := `SELECT ` + retentionRunColumns + ` FROM retention_runs WHERE id = $1`
+	run, err := scanRetentionRun(db.conn.QueryRow(query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention run: %w", err)
+	}
+	return run, nil
+}
+
+// ListRetentionRuns retorna las ejecuciones más recientes primero
+func (db *DB) ListRetentionRuns(limit int) ([]models.RetentionRun, error) {
+	query := `SELECT ` + retentionRunColumns + ` FROM retention_runs ORDER BY started_at DESC, id DESC LIMIT $1`
+	rows, err := db.conn.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.RetentionRun{}
+	for rows.Next() {
+		run, err := scanRetentionRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/blob"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// blobExists indica si el blob store de db todavía guarda el contenido
+func blobExists(t *testing.T, db *DB, content string) bool {
+	t.Helper()
+
+	_, err := db.blobs.Get(context.Background(), blob.Key([]byte(content)))
+	if errors.Is(err, blob.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("blob Get: %v", err)
+	}
+	return true
+}
+
+func TestDeleteSubmissionsReleasesBlobs(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BlobBackend = blob.BackendFS
+	cfg.BlobFSDir = filepath.Join(t.TempDir(), "blobs")
+	cfg.BlobThreshold = 16
+	db := openTestDB(t, cfg)
+
+	shared := "print('" + strings.Repeat("x", 64) + "')"
+	unique := strings.Repeat("entrada ", 16)
+	now := time.Now().UTC()
+	subs := []*models.Submission{
+		{ID: "s1", LanguageID: 71, SourceCode: shared, Stdin: unique, Status: models.StatusCompleted, CreatedAt: now},
+		{ID: "s2", LanguageID: 71, SourceCode: shared, Status: models.StatusCompleted, CreatedAt: now},
+	}
+	if err := db.CreateSubmissions(subs); err != nil {
+		t.Fatalf("CreateSubmissions: %v", err)
+	}
+	if !blobExists(t, db, shared) || !blobExists(t, db, unique) {
+		t.Fatal("payloads over the threshold were not offloaded")
+	}
+
+	deleted, _, err := db.DeleteSubmissions([]string{"s1"})
+	if err != nil {
+		t.Fatalf("DeleteSubmissions: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("deleted %d submissions, want 1", deleted)
+	}
+	if blobExists(t, db, unique) {
+		t.Fatal("blob referenced only by the deleted submission was kept")
+	}
+	if !blobExists(t, db, shared) {
+		t.Fatal("blob still referenced by s2 was deleted")
+	}
+
+	// La submission que sigue viva lee su código del blob compartido
+	got, err := db.GetSubmission("s2")
+	if err != nil {
+		t.Fatalf("GetSubmission: %v", err)
+	}
+	if got.SourceCode != shared {
+		t.Fatalf("source code = %q, want the shared payload", got.SourceCode)
+	}
+
+	if _, _, err := db.DeleteSubmissions([]string{"s2"}); err != nil {
+		t.Fatalf("DeleteSubmissions: %v", err)
+	}
+	if blobExists(t, db, shared) {
+		t.Fatal("blob without references was kept")
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/blob"
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// testConfig arma una configuración SQLite en un directorio temporal, sin blob store
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	return &config.Config{
+		DBDriver:       DriverSQLite,
+		SQLitePath:     filepath.Join(t.TempDir(), "rojudger.db"),
+		IdempotencyTTL: time.Hour,
+		BlobDelivery:   blob.DeliveryInline,
+		BlobThreshold:  64 * 1024,
+	}
+}
+
+// openTestDB abre la base de cfg con el esquema migrado y los lenguajes iniciales
+func openTestDB(t *testing.T, cfg *config.Config) *DB {
+	t.Helper()
+
+	db, err := NewDB(cfg)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := db.SeedLanguages(); err != nil {
+		t.Fatalf("SeedLanguages: %v", err)
+	}
+	return db
+}
+
+// newTestDB es openTestDB con la configuración por defecto de testConfig
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	return openTestDB(t, testConfig(t))
+}
+
+// newTestSubmission crea una submission de Python en cola creada en createdAt
+func newTestSubmission(t *testing.T, db *DB, id string, createdAt time.Time) *models.Submission {
+	t.Helper()
+
+	sub := &models.Submission{
+		ID:         id,
+		LanguageID: 71,
+		SourceCode: "print('" + id + "')",
+		Status:     models.StatusQueued,
+		ExitCode:   -1,
+		CreatedAt:  createdAt.UTC(),
+	}
+	if err := db.CreateSubmission(sub); err != nil {
+		t.Fatalf("CreateSubmission(%s): %v", id, err)
+	}
+	return sub
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// SubmissionStore guarda las submissions y sus resultados
+type SubmissionStore interface {
+	CreateSubmission(sub *models.Submission) error
+	CreateSubmissions(subs []*models.Submission) error
+	GetSubmission(id string) (*models.Submission, error)
+	GetSubmissionsByIDs(ids []string) ([]models.Submission, error)
+	GetSubmissionsByStatus(status string, limit int) ([]models.Submission, error)
+	UpdateSubmission(sub *models.Submission) error
+	UpdateSubmissionStatus(id, status string) error
+	ListSubmissions(scope SubmissionScope, offset, limit int) ([]models.Submission, error)
+	CountSubmissions(scope SubmissionScope) (int, error)
+	QuerySubmissions(q SubmissionQuery) ([]models.Submission, *SubmissionCursor, error)
+}
+
+// LanguageStore guarda los lenguajes soportados y su historial de cambios
+type LanguageStore interface {
+	SeedLanguages() error
+	GetLanguage(id int) (*models.Language, error)
+	GetLanguageAnyState(id int) (*models.Language, error)
+	GetAllLanguages() ([]models.Language, error)
+	ListAllLanguages() ([]models.Language, error)
+	CreateLanguage(lang *models.Language, rev *models.LanguageRevision) error
+	UpdateLanguage(lang *models.Language, rev *models.LanguageRevision) error
+	ListLanguageRevisions(languageID int) ([]models.LanguageRevision, error)
+	GetLanguageRevision(languageID, revision int) (*models.LanguageRevision, error)
+}
+
+// WebhookLogStore registra los intentos de entrega de webhooks
+type WebhookLogStore interface {
+	LogWebhookAttempt(submissionID, webhookURL string, attempt, statusCode int, responseBody, errorMsg string) error
+}
+
+// IdempotencyStore guarda los Idempotency-Key de las peticiones de creación
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(key, fingerprint, submissionID string) (*models.IdempotencyKey, bool, error)
+	DeleteIdempotencyKey(key string) error
+}
+
+// APIKeyStore guarda las API keys (solo sus hashes)
+type APIKeyStore interface {
+	CreateAPIKey(key *models.APIKey) error
+	GetAPIKeyByHash(hash string) (*models.APIKey, error)
+	ListAPIKeys(tenantID string) ([]models.APIKey, error)
+	RevokeAPIKey(id, tenantID string) (bool, error)
+	TouchAPIKey(id string) error
+}
+
+// TenantStore guarda los tenants y su configuración
+type TenantStore interface {
+	CreateTenant(tenant *models.Tenant) error
+	GetTenant(id string) (*models.Tenant, error)
+	ListTenants() ([]models.Tenant, error)
+	UpdateTenant(tenant *models.Tenant) (bool, error)
+}
+
+// UsageStore guarda el ledger de consumo
+type UsageStore interface {
+	RecordUsage(record *models.UsageRecord) error
+	SummarizeUsage(q UsageQuery) ([]models.UsageSummary, error)
+}
+
+// RetentionStore purga submissions vencidas y guarda el historial del janitor
+type RetentionStore interface {
+	ExpiredPayloadIDs(scope RetentionScope, before time.Time, limit int) ([]string, error)
+	ExpiredSubmissionIDs(scope RetentionScope, before time.Time, limit int) ([]string, error)
+	PurgeSubmissionPayloads(ids []string, purgedAt time.Time) (int64, error)
+	DeleteSubmissions(ids []string) (submissions int64, webhookLogs int64, err error)
+	GetSubmissionsForArchive(ids []string) ([]models.Submission, error)
+	GetWebhookLogs(submissionIDs []string) ([]models.WebhookLog, error)
+	StartRetentionRun(run *models.RetentionRun) (bool, error)
+	UpdateRetentionRun(run *models.RetentionRun) error
+	AbandonRetentionRuns(before time.Time) (int64, error)
+	GetRetentionRun(id int64) (*models.RetentionRun, error)
+	ListRetentionRuns(limit int) ([]models.RetentionRun, error)
+}
+
+// Store es todo el almacenamiento que usan el API y los workers. DB lo implementa
+// sobre PostgreSQL o SQLite; los tests pueden usar SQLite en memoria.
+type Store interface {
+	SubmissionStore
+	LanguageStore
+	WebhookLogStore
+	IdempotencyStore
+	APIKeyStore
+	TenantStore
+	UsageStore
+	RetentionStore
+
+	Health() error
+	Close() error
+}
+
+var _ Store = (*DB)(nil)
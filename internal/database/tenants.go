@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// tenantColumns son las columnas que lee scanTenant, en orden
+const tenantColumns = `id, name, enabled_languages, max_time_limit, max_memory_mb, max_cpus, webhook_secret, plan, created_at, updated_at`
+
+// scanTenant lee una fila con tenantColumns
+func (db *DB) scanTenant(row rowScanner) (*models.Tenant, error) {
+	var tenant models.Tenant
+	var languages []int64
+
+	err := row.Scan(
+		&tenant.ID, &tenant.Name, db.dialect.array(&languages), &tenant.MaxTimeLimit, &tenant.MaxMemoryMB,
+		&tenant.MaxCPUs, &tenant.WebhookSecret, &tenant.Plan, &tenant.CreatedAt, &tenant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.EnabledLanguages = make([]int, len(languages))
+	for i, id := range languages {
+		tenant.EnabledLanguages[i] = int(id)
+	}
+	return &tenant, nil
+}
+
+// languageArray convierte la lista de lenguajes en el argumento de la columna
+func (db *DB) languageArray(ids []int) interface{} {
+	array := make([]int64, len(ids))
+	for i, id := range ids {
+		array[i] = int64(id)
+	}
+	return db.dialect.array(array)
+}
+
+// CreateTenant guarda un tenant nuevo
+func (db *DB) CreateTenant(tenant *models.Tenant) error {
+	query := `
+	INSERT INTO tenants (id, name, enabled_languages, max_time_limit, max_memory_mb, max_cpus, webhook_secret, plan)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING created_at, updated_at
+	`
+	err := db.conn.QueryRow(query,
+		tenant.ID, tenant.Name, db.languageArray(tenant.EnabledLanguages), tenant.MaxTimeLimit,
+		tenant.MaxMemoryMB, tenant.MaxCPUs, tenant.WebhookSecret, tenant.Plan,
+	).Scan(&tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+	return nil
+}
+
+// GetTenant obtiene un tenant por ID
+func (db *DB) GetTenant(id string) (*models.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE id = $1`
+
+	tenant, err := db.scanTenant(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tenant not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+// ListTenants retorna todos los tenants ordenados por ID
+func (db *DB) ListTenants() ([]models.Tenant, error) {
+	rows, err := db.conn.Query(`SELECT ` + tenantColumns + ` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []models.Tenant{}
+	for rows.Next() {
+		tenant, err := db.scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, *tenant)
+	}
+	return tenants, rows.Err()
+}
+
+// UpdateTenant guarda la configuración de un tenant. Retorna false si no existe.
+func (db *DB) UpdateTenant(tenant *models.Tenant) (bool, error) {
+	query := `
+	UPDATE tenants
+	SET name = $2, enabled_languages = $3, max_time_limit = $4, max_memory_mb = $5,
+	    max_cpus = $6, webhook_secret = $7, plan = $8, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	RETURNING created_at, updated_at
+	`
+	err := db.conn.QueryRow(query,
+		tenant.ID, tenant.Name, db.languageArray(tenant.EnabledLanguages), tenant.MaxTimeLimit,
+		tenant.MaxMemoryMB, tenant.MaxCPUs, tenant.WebhookSecret, tenant.Plan,
+	).Scan(&tenant.CreatedAt, &tenant.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update tenant: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// UsageQuery filtra el consumo agregado. To es exclusivo.
+type UsageQuery struct {
+	From     time.Time
+	To       time.Time
+	TenantID string // "" = todos
+	APIKeyID string // "" = todas
+}
+
+// RecordUsage agrega una submission al ledger de consumo. Registrar dos veces
+// la misma submission (p.ej. un reintento del worker) no duplica el cobro.
+func (db *DB) RecordUsage(record *models.UsageRecord) error {
+	query := `
+	INSERT INTO usage_records (
+		submission_id, tenant_id, api_key_id, user_id, language_id, language_name,
+		cpu_seconds, wall_seconds, memory_mb_seconds, compile_seconds, recorded_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (submission_id) DO NOTHING
+	`
+	_, err := db.conn.Exec(query,
+		record.SubmissionID, record.TenantID, record.APIKeyID, record.UserID, record.LanguageID,
+		record.LanguageName, record.CPUSeconds, record.WallSeconds, record.MemoryMBSeconds,
+		record.CompileSeconds, record.RecordedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// SummarizeUsage agrega el ledger por día (UTC), tenant y API key
+func (db *DB) SummarizeUsage(q UsageQuery) ([]models.UsageSummary, error) {
+	conditions := []string{"recorded_at >= $1", "recorded_at < $2"}
+	args := []interface{}{q.From.UTC(), q.To.UTC()}
+
+	if q.TenantID != "" {
+		args = append(args, q.TenantID)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+	if q.APIKeyID != "" {
+		args = append(args, q.APIKeyID)
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)))
+	}
+
+	query := `
+	SELECT ` + db.dialect.day("recorded_at") + `, tenant_id, api_key_id, COUNT(*),
+	       SUM(cpu_seconds), SUM(wall_seconds), SUM(memory_mb_seconds), SUM(compile_seconds)
+	FROM usage_records
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	GROUP BY 1, 2, 3
+	ORDER BY 1, 2, 3
+	`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize usage: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []models.UsageSummary{}
+	for rows.Next() {
+		var summary models.UsageSummary
+		err := rows.Scan(
+			&summary.Day, &summary.TenantID, &summary.APIKeyID, &summary.Submissions,
+			&summary.CPUSeconds, &summary.WallSeconds, &summary.MemoryMBSeconds, &summary.CompileSeconds,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
@@ -58,6 +58,12 @@ func NewExecutor(cfg *config.Config) (*Executor, error) {
 
 // Execute ejecuta el código en un contenedor Docker aislado
 func (e *Executor) Execute(ctx context.Context, submission *models.Submission, language *models.Language) models.ExecutionResult {
+	return e.ExecuteStreaming(ctx, submission, language, nil)
+}
+
+// ExecuteStreaming ejecuta el código igual que Execute, pero entrega stdout/stderr
+// a onOutput a medida que se producen. El resultado final contiene la salida completa.
+func (e *Executor) ExecuteStreaming(ctx context.Context, submission *models.Submission, language *models.Language, onOutput OutputFunc) models.ExecutionResult {
 	// Limitar concurrencia
 	e.rateLimiter <- struct{}{}
 	defer func() { <-e.rateLimiter }()
@@ -66,15 +72,15 @@ func (e *Executor) Execute(ctx context.Context, submission *models.Submission, l
 		ExitCode: -1,
 	}
 
-	// Crear contexto con timeout
-	execCtx, cancel := context.WithTimeout(ctx, e.config.ExecutorTimeout)
+	// Crear contexto con timeout (el del tenant si es menor que el global)
+	execCtx, cancel := context.WithTimeout(ctx, e.limits(ctx).Timeout)
 	defer cancel()
 
 	startTime := time.Now()
 
 	// Para lenguajes compilados, compilar y ejecutar en el mismo contenedor
 	if language.IsCompiled {
-		return e.compileAndExecute(execCtx, submission, language, startTime)
+		return e.compileAndExecute(execCtx, submission, language, startTime, onOutput)
 	}
 
 	// Ejecutar el código (lenguajes interpretados)
@@ -93,6 +99,9 @@ func (e *Executor) Execute(ctx context.Context, submission *models.Submission, l
 		return result
 	}
 
+	// Seguir la salida mientras corre
+	follower := e.followLogs(containerID, onOutput)
+
 	// Enviar stdin si existe
 	if submission.Stdin != "" {
 		if err := e.writeStdin(execCtx, containerID, submission.Stdin); err != nil {
@@ -121,7 +130,7 @@ func (e *Executor) Execute(ctx context.Context, submission *models.Submission, l
 	result.Time = time.Since(startTime).Seconds()
 
 	// Obtener logs (stdout y stderr)
-	stdout, stderr, err := e.getLogs(context.Background(), containerID)
+	stdout, stderr, err := e.collectLogs(follower, containerID)
 	if err != nil {
 		log.Printf("Warning: failed to get logs: %v", err)
 	}
@@ -133,6 +142,8 @@ func (e *Executor) Execute(ctx context.Context, submission *models.Submission, l
 	stats, err := e.getStats(context.Background(), containerID)
 	if err == nil {
 		result.Memory = stats.MemoryUsageKB
+		result.PeakMemory = stats.PeakMemoryKB
+		result.CPUTime = stats.CPUSeconds
 	}
 
 	return result
@@ -143,24 +154,32 @@ func (e *Executor) createContainer(ctx context.Context, submission *models.Submi
 	// Preparar el comando de ejecución
 	cmd := e.buildExecuteCommand(submission, language)
 
+	return e.newContainer(ctx, language.DockerImage, cmd, false, nil)
+}
+
+// newContainer crea un contenedor con los límites y restricciones de seguridad comunes.
+// Con tty=true el stdin queda abierto y la salida no se multiplexa (sesiones interactivas).
+func (e *Executor) newContainer(ctx context.Context, image string, cmd []string, tty bool, labels map[string]string) (string, error) {
 	// Configurar límites de recursos
+	limits := e.limits(ctx)
 	resources := container.Resources{
-		Memory:   parseMemoryLimit(e.config.ExecutorMemoryLimit), // 256MB por defecto
-		NanoCPUs: parseCPULimit(e.config.ExecutorCPULimit),       // 0.5 CPUs por defecto
+		Memory:   limits.MemoryBytes, // 256MB por defecto
+		NanoCPUs: limits.NanoCPUs,    // 0.5 CPUs por defecto
 	}
 
 	// Configuración del contenedor
 	containerConfig := &container.Config{
-		Image:           language.DockerImage,
+		Image:           image,
 		Cmd:             cmd,
-		Tty:             false,
+		Tty:             tty,
 		AttachStdin:     true,
 		AttachStdout:    true,
 		AttachStderr:    true,
 		OpenStdin:       true,
-		StdinOnce:       true,
+		StdinOnce:       !tty, // en sesiones el stdin sigue abierto entre reconexiones
 		WorkingDir:      "/workspace",
 		NetworkDisabled: true, // Deshabilitar red por seguridad
+		Labels:          labels,
 	}
 
 	hostConfig := &container.HostConfig{
@@ -246,46 +265,35 @@ func (e *Executor) getLogs(ctx context.Context, containerID string) (string, str
 	// Docker multiplexa stdout/stderr en un stream
 	// Necesitamos demultiplexarlo
 	var stdout, stderr strings.Builder
-
-	// Buffer temporal
-	buf := make([]byte, 8)
-
-	for {
-		// Leer header (8 bytes)
-		n, err := logs.Read(buf)
-		if err == io.EOF {
-			break
-		}
-		if err != nil || n < 8 {
-			break
-		}
-
-		// byte 0: stream type (1=stdout, 2=stderr)
-		streamType := buf[0]
-		// bytes 4-7: tamaño del payload
-		size := int(buf[4])<<24 | int(buf[5])<<16 | int(buf[6])<<8 | int(buf[7])
-
-		// Leer payload
-		payload := make([]byte, size)
-		_, err = io.ReadFull(logs, payload)
-		if err != nil {
-			break
-		}
-
+	err = demuxStream(logs, func(streamType byte, payload []byte) {
 		// Escribir al stream correspondiente
 		if streamType == 1 {
 			stdout.Write(payload)
 		} else if streamType == 2 {
 			stderr.Write(payload)
 		}
+	})
+
+	return stdout.String(), stderr.String(), err
+}
+
+// collectLogs obtiene la salida completa del follower; si el stream falló,
+// vuelve a leer los logs del contenedor
+func (e *Executor) collectLogs(follower *logFollower, containerID string) (string, string, error) {
+	stdout, stderr, err := follower.wait(5 * time.Second)
+	if err == nil {
+		return stdout, stderr, nil
 	}
 
-	return stdout.String(), stderr.String(), nil
+	log.Printf("Warning: log follow failed (%v), reading logs again", err)
+	return e.getLogs(context.Background(), containerID)
 }
 
 // ContainerStats representa estadísticas del contenedor
 type ContainerStats struct {
 	MemoryUsageKB int
+	PeakMemoryKB  int     // pico de memoria (igual al uso actual si Docker no lo reporta)
+	CPUSeconds    float64 // tiempo de CPU consumido por el contenedor
 }
 
 // getStats obtiene estadísticas del contenedor
@@ -305,12 +313,17 @@ func (e *Executor) getStats(ctx context.Context, containerID string) (ContainerS
 
 	// Convertir bytes a KB
 	stats.MemoryUsageKB = int(containerStats.MemoryStats.Usage / 1024)
+	stats.PeakMemoryKB = stats.MemoryUsageKB
+	if peak := int(containerStats.MemoryStats.MaxUsage / 1024); peak > stats.PeakMemoryKB {
+		stats.PeakMemoryKB = peak
+	}
+	stats.CPUSeconds = float64(containerStats.CPUStats.CPUUsage.TotalUsage) / 1e9
 
 	return stats, nil
 }
 
 // compileAndExecute compila y ejecuta el código en el mismo contenedor
-func (e *Executor) compileAndExecute(ctx context.Context, submission *models.Submission, language *models.Language, startTime time.Time) models.ExecutionResult {
+func (e *Executor) compileAndExecute(ctx context.Context, submission *models.Submission, language *models.Language, startTime time.Time, onOutput OutputFunc) models.ExecutionResult {
 	result := models.ExecutionResult{
 		ExitCode: -1,
 	}
@@ -326,13 +339,14 @@ func (e *Executor) compileAndExecute(ctx context.Context, submission *models.Sub
 	// Si la compilación falla, no ejecutamos
 	fullCmd := []string{
 		"sh", "-c",
-		fmt.Sprintf("%s && cd /workspace && %s && %s", createFile, compileCmd, executeCmd),
+		fmt.Sprintf("%s && cd /workspace && %s && %s", createFile, timedCompile(compileCmd), executeCmd),
 	}
 
 	// Configurar límites de recursos
+	limits := e.limits(ctx)
 	resources := container.Resources{
-		Memory:   parseMemoryLimit(e.config.ExecutorMemoryLimit),
-		NanoCPUs: parseCPULimit(e.config.ExecutorCPULimit),
+		Memory:   limits.MemoryBytes,
+		NanoCPUs: limits.NanoCPUs,
 	}
 
 	containerConfig := &container.Config{
@@ -370,6 +384,9 @@ func (e *Executor) compileAndExecute(ctx context.Context, submission *models.Sub
 		return result
 	}
 
+	// Seguir la salida mientras corre
+	follower := e.followLogs(resp.ID, onOutput)
+
 	// Enviar stdin si existe
 	if submission.Stdin != "" {
 		if err := e.writeStdin(ctx, resp.ID, submission.Stdin); err != nil {
@@ -396,10 +413,12 @@ func (e *Executor) compileAndExecute(ctx context.Context, submission *models.Sub
 	result.Time = time.Since(startTime).Seconds()
 
 	// Obtener logs
-	stdout, stderr, _ := e.getLogs(context.Background(), resp.ID)
+	stdout, stderr, _ := e.collectLogs(follower, resp.ID)
 	result.Stdout = stdout
 	result.Stderr = stderr
 
+	result.CompileTime = e.readCompileTime(resp.ID, result.Time)
+
 	// Separar output de compilación del output de ejecución
 	// Si hay error de compilación, estará en stderr
 	if result.ExitCode != 0 && strings.Contains(stderr, "error:") {
@@ -411,6 +430,8 @@ func (e *Executor) compileAndExecute(ctx context.Context, submission *models.Sub
 	stats, err := e.getStats(context.Background(), resp.ID)
 	if err == nil {
 		result.Memory = stats.MemoryUsageKB
+		result.PeakMemory = stats.PeakMemoryKB
+		result.CPUTime = stats.CPUSeconds
 	}
 
 	return result
@@ -430,6 +451,31 @@ func (e *Executor) cleanup(containerID string) {
 	})
 }
 
+// ImageAvailable verifica si una imagen Docker ya está descargada localmente
+func (e *Executor) ImageAvailable(ctx context.Context, image string) bool {
+	_, _, err := e.client.ImageInspectWithRaw(ctx, image)
+	return err == nil
+}
+
+// PullImage descarga una imagen Docker si no está disponible localmente
+func (e *Executor) PullImage(ctx context.Context, image string) error {
+	if e.ImageAvailable(ctx, image) {
+		return nil
+	}
+
+	reader, err := e.client.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	defer reader.Close()
+
+	// El progreso llega como stream JSON; hay que consumirlo hasta el final
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
 // Close cierra el cliente Docker
 func (e *Executor) Close() error {
 	return e.client.Close()
@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// Limits son los recursos máximos de una ejecución. Un valor 0 usa el límite global.
+type Limits struct {
+	Timeout     time.Duration
+	MemoryBytes int64
+	NanoCPUs    int64
+}
+
+type limitsKey struct{}
+
+// WithLimits retorna un contexto cuyas ejecuciones usan limits como techo.
+// Nunca se superan los límites globales del executor.
+func WithLimits(ctx context.Context, limits Limits) context.Context {
+	return context.WithValue(ctx, limitsKey{}, limits)
+}
+
+// TenantLimits convierte los techos configurados de un tenant
+func TenantLimits(tenant *models.Tenant) Limits {
+	return Limits{
+		Timeout:     time.Duration(tenant.MaxTimeLimit * float64(time.Second)),
+		MemoryBytes: int64(tenant.MaxMemoryMB) * 1024 * 1024,
+		NanoCPUs:    int64(tenant.MaxCPUs * 1e9),
+	}
+}
+
+// limits calcula los límites efectivos: los globales, reducidos por los del contexto
+func (e *Executor) limits(ctx context.Context) Limits {
+	limits := Limits{
+		Timeout:     e.config.ExecutorTimeout,
+		MemoryBytes: parseMemoryLimit(e.config.ExecutorMemoryLimit),
+		NanoCPUs:    parseCPULimit(e.config.ExecutorCPULimit),
+	}
+
+	ceiling, ok := ctx.Value(limitsKey{}).(Limits)
+	if !ok {
+		return limits
+	}
+	if ceiling.Timeout > 0 && ceiling.Timeout < limits.Timeout {
+		limits.Timeout = ceiling.Timeout
+	}
+	if ceiling.MemoryBytes > 0 && ceiling.MemoryBytes < limits.MemoryBytes {
+		limits.MemoryBytes = ceiling.MemoryBytes
+	}
+	if ceiling.NanoCPUs > 0 && (limits.NanoCPUs == 0 || ceiling.NanoCPUs < limits.NanoCPUs) {
+		limits.NanoCPUs = ceiling.NanoCPUs
+	}
+	return limits
+}
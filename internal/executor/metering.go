@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// compileTimeFile guarda los instantes (de /proc/uptime) de inicio y fin de la compilación
+const compileTimeFile = "/tmp/.rojudger_compile_time"
+
+// timedCompile envuelve el comando de compilación para medir su duración dentro del
+// contenedor. Solo usa builtins de sh, así funciona con cualquier imagen, y conserva
+// el código de salida del compilador.
+func timedCompile(compileCmd string) string {
+	return fmt.Sprintf(
+		`{ read rj_start _ < /proc/uptime; %s; rj_rc=$?; read rj_end _ < /proc/uptime; echo "$rj_start $rj_end" > %s; (exit $rj_rc); }`,
+		compileCmd, compileTimeFile,
+	)
+}
+
+// readCompileTime lee la duración de la compilación del contenedor ya detenido.
+// Retorna 0 si no se llegó a compilar. Se acota a wall porque el programa podría
+// sobrescribir el archivo.
+func (e *Executor) readCompileTime(containerID string, wall float64) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reader, _, err := e.client.CopyFromContainer(ctx, containerID, compileTimeFile)
+	if err != nil {
+		return 0
+	}
+	defer reader.Close()
+
+	// Docker entrega el archivo dentro de un tar
+	archive := tar.NewReader(reader)
+	if _, err := archive.Next(); err != nil {
+		return 0
+	}
+	content, err := io.ReadAll(io.LimitReader(archive, 128))
+	if err != nil {
+		return 0
+	}
+
+	var start, end float64
+	if _, err := fmt.Sscanf(string(content), "%f %f", &start, &end); err != nil {
+		return 0
+	}
+
+	seconds := end - start
+	if seconds < 0 {
+		return 0
+	}
+	if seconds > wall {
+		return wall
+	}
+	return seconds
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// sessionLabel marca los contenedores de sesiones interactivas para poder limpiarlos
+const sessionLabel = "rojudger.session"
+
+// Session es una terminal interactiva (TTY) dentro de un contenedor aislado
+type Session struct {
+	ContainerID string
+
+	executor  *Executor
+	conn      types.HijackedResponse
+	closeOnce sync.Once
+}
+
+// StartSession crea y arranca un contenedor con TTY y stdin adjunto para el lenguaje.
+// El llamador debe cerrar la sesión con Close.
+func (e *Executor) StartSession(ctx context.Context, sessionID string, language *models.Language) (*Session, error) {
+	labels := map[string]string{sessionLabel: sessionID}
+
+	containerID, err := e.newContainer(ctx, language.DockerImage, interactiveCommand(language), true, labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session container: %w", err)
+	}
+
+	// Adjuntar antes de arrancar para no perder el prompt inicial
+	conn, err := e.client.ContainerAttach(ctx, containerID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		e.cleanup(containerID)
+		return nil, fmt.Errorf("failed to attach to session container: %w", err)
+	}
+
+	if err := e.client.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
+		conn.Close()
+		e.cleanup(containerID)
+		return nil, fmt.Errorf("failed to start session container: %w", err)
+	}
+
+	return &Session{
+		ContainerID: containerID,
+		executor:    e,
+		conn:        conn,
+	}, nil
+}
+
+// Read lee la salida de la terminal (con TTY no hay multiplexado de stdout/stderr)
+func (s *Session) Read(p []byte) (int, error) {
+	return s.conn.Reader.Read(p)
+}
+
+// Write envía entrada a la terminal
+func (s *Session) Write(p []byte) (int, error) {
+	return s.conn.Conn.Write(p)
+}
+
+// Resize ajusta el tamaño de la terminal
+func (s *Session) Resize(ctx context.Context, rows, cols uint) error {
+	return s.executor.client.ContainerResize(ctx, s.ContainerID, container.ResizeOptions{
+		Height: rows,
+		Width:  cols,
+	})
+}
+
+// Close desconecta la terminal y elimina el contenedor
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		s.conn.Close()
+		s.executor.cleanup(s.ContainerID)
+	})
+	return nil
+}
+
+// CleanupSessions elimina contenedores de sesiones que quedaron huérfanos
+// (p.ej. si el proceso anterior terminó sin cerrarlas). Retorna cuántos eliminó.
+func (e *Executor) CleanupSessions(ctx context.Context) (int, error) {
+	containers, err := e.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", sessionLabel)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list session containers: %w", err)
+	}
+
+	for _, c := range containers {
+		e.cleanup(c.ID)
+	}
+	return len(containers), nil
+}
+
+// interactiveCommand retorna el REPL del lenguaje, o una shell para los compilados
+func interactiveCommand(language *models.Language) []string {
+	switch language.ID {
+	case models.LanguagePython3:
+		return []string{"python3", "-i"}
+	case models.LanguageJavaScript:
+		return []string{"node", "-i"}
+	default:
+		// Shell con el toolchain de la imagen (gcc, go, rustc, javac...)
+		return []string{"sh"}
+	}
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Nombres de los streams de salida
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputFunc recibe fragmentos de salida a medida que el programa los produce
+type OutputFunc func(stream string, data []byte)
+
+// logFollower sigue los logs de un contenedor mientras corre, demultiplexando
+// stdout/stderr de forma incremental
+type logFollower struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	stdout strings.Builder
+	stderr strings.Builder
+	err    error
+}
+
+// followLogs empieza a seguir los logs del contenedor (Follow: true).
+// onOutput puede ser nil si solo interesa la salida final.
+func (e *Executor) followLogs(containerID string, onOutput OutputFunc) *logFollower {
+	// No usar el contexto de ejecución: al hacer timeout queremos los logs igual
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &logFollower{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(f.done)
+
+		logs, err := e.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+			ShowStdout: true,
+			ShowStderr: true,
+			Follow:     true,
+		})
+		if err != nil {
+			f.err = err
+			return
+		}
+		defer logs.Close()
+
+		f.err = demuxStream(logs, func(streamType byte, payload []byte) {
+			var stream string
+			switch streamType {
+			case 1:
+				f.stdout.Write(payload)
+				stream = StreamStdout
+			case 2:
+				f.stderr.Write(payload)
+				stream = StreamStderr
+			default:
+				return
+			}
+			if onOutput != nil {
+				onOutput(stream, payload)
+			}
+		})
+	}()
+
+	return f
+}
+
+// wait espera a que termine el stream de logs (el contenedor ya se detuvo) y
+// retorna la salida completa. Si no termina antes de timeout retorna error.
+func (f *logFollower) wait(timeout time.Duration) (string, string, error) {
+	select {
+	case <-f.done:
+	case <-time.After(timeout):
+		f.cancel()
+		<-f.done
+		return "", "", fmt.Errorf("log stream did not finish within %v", timeout)
+	}
+	f.cancel()
+
+	if f.err != nil {
+		return "", "", f.err
+	}
+	return f.stdout.String(), f.stderr.String(), nil
+}
+
+// demuxStream lee el stream multiplexado de Docker y llama onFrame por cada frame.
+// Cada frame tiene un header de 8 bytes: byte 0 = stream (1=stdout, 2=stderr),
+// bytes 4-7 = tamaño del payload (big endian).
+func demuxStream(r io.Reader, onFrame func(streamType byte, payload []byte)) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+
+		size := binary.BigEndian.Uint32(header[4:8])
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+
+		onFrame(header[0], payload)
+	}
+}
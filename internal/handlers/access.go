@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/RobertoRochaT/rojudger/internal/auth"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// requestOwner retorna la API key que se registra como dueña de las submissions creadas por la petición
+func requestOwner(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil {
+		return p.Owner()
+	}
+	return ""
+}
+
+// requestUser retorna el usuario al que se atribuye la submission: el del JWT si la
+// petición trae uno (no se puede suplantar desde el body), si no el indicado por el
+// cliente. Este último es solo una etiqueta para filtrar: no da propiedad (ver owns).
+func requestUser(c *gin.Context, requested string) string {
+	if p := auth.FromContext(c); p != nil && p.UserID != "" {
+		return p.UserID
+	}
+	return requested
+}
+
+// requestSubject identifica al cliente para separar sus recursos ("" sin autenticación)
+func requestSubject(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil {
+		return p.Subject()
+	}
+	return ""
+}
+
+// requestTenantID retorna el tenant del principal ("" = global)
+func requestTenantID(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil {
+		return p.TenantID
+	}
+	return ""
+}
+
+// requestTenant carga la configuración del tenant del principal (nil si no tiene).
+// Si falla escribe la respuesta de error y retorna ok = false.
+func requestTenant(c *gin.Context, db database.Store) (tenant *models.Tenant, ok bool) {
+	tenantID := requestTenantID(c)
+	if tenantID == "" {
+		return nil, true
+	}
+
+	tenant, err := db.GetTenant(tenantID)
+	if err != nil {
+		log.Printf("Failed to load tenant %s: %v", tenantID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load tenant"})
+		return nil, false
+	}
+	return tenant, true
+}
+
+// languageAllowed indica si el tenant (nil = sin restricción) puede usar el lenguaje
+func languageAllowed(tenant *models.Tenant, languageID int) bool {
+	return tenant == nil || tenant.LanguageEnabled(languageID)
+}
+
+// visibleLanguages retorna los lenguajes que puede usar la petición (los de su tenant).
+// Si falla escribe la respuesta de error y retorna ok = false.
+func visibleLanguages(c *gin.Context, db database.Store) ([]models.Language, bool) {
+	tenant, ok := requestTenant(c, db)
+	if !ok {
+		return nil, false
+	}
+
+	languages, err := db.GetAllLanguages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get languages"})
+		return nil, false
+	}
+
+	visible := make([]models.Language, 0, len(languages))
+	for _, language := range languages {
+		if languageAllowed(tenant, language.ID) {
+			visible = append(visible, language)
+		}
+	}
+	return visible, true
+}
+
+// readFilter retorna las submissions que puede listar la petición: las de su tenant
+// y, con read-own, solo las de su API key o usuario
+func readFilter(c *gin.Context) database.SubmissionScope {
+	p := auth.FromContext(c)
+	if p == nil {
+		return database.SubmissionScope{}
+	}
+
+	scope := database.SubmissionScope{TenantID: p.TenantID}
+	if p.CanReadAll() {
+		return scope
+	}
+	scope.Own = true
+	if p.UserID != "" {
+		scope.UserID = p.UserID
+	} else {
+		scope.APIKeyID = p.Owner()
+	}
+	return scope
+}
+
+// canRead indica si la petición puede ver la submission:
+// con read-all cualquiera de su tenant, con read-own solo las propias
+func canRead(c *gin.Context, submission *models.Submission) bool {
+	p := auth.FromContext(c)
+	if p == nil {
+		return true
+	}
+	if p.CanReadAll() {
+		return sameTenant(p, submission)
+	}
+	return p.HasScope(auth.ScopeReadOwn) && owns(p, submission)
+}
+
+// canModify indica si la petición puede modificar (p.ej. cancelar) la submission
+func canModify(c *gin.Context, submission *models.Submission) bool {
+	p := auth.FromContext(c)
+	if p == nil {
+		return true
+	}
+	if p.HasScope(auth.ScopeAdmin) {
+		return sameTenant(p, submission)
+	}
+	return owns(p, submission)
+}
+
+// sameTenant indica si la submission es visible para el tenant del principal
+// (los principals globales ven todas)
+func sameTenant(p *auth.Principal, submission *models.Submission) bool {
+	return p.TenantID == "" || submission.TenantID == p.TenantID
+}
+
+// owns indica si la submission pertenece al principal: debe ser de su mismo tenant
+// (exacto: un principal global solo es dueño de submissions globales) y, si viene
+// de un JWT, creada con un JWT de ese usuario; si no, creada con su API key.
+// El user_id que una API key pone en el body no da propiedad.
+func owns(p *auth.Principal, submission *models.Submission) bool {
+	if submission.TenantID != p.TenantID {
+		return false
+	}
+	if p.UserID != "" {
+		return submission.APIKeyID == "" && submission.UserID == p.UserID
+	}
+	return p.Owner() != "" && submission.APIKeyID == p.Owner()
+}
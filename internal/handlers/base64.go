@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// base64Requested indica si el cliente envía y recibe los textos en base64 (base64_encoded=true)
+func base64Requested(c *gin.Context) bool {
+	return c.Query("base64_encoded") == "true"
+}
+
+// decodeBase64 decodifica source_code, stdin y expected_output enviados en base64
+func (r *CreateSubmissionRequest) decodeBase64() error {
+	var err error
+	if r.SourceCode, err = decodeBase64Field("source_code", r.SourceCode); err != nil {
+		return err
+	}
+	if r.Stdin, err = decodeBase64Field("stdin", r.Stdin); err != nil {
+		return err
+	}
+	if r.ExpectedOutput, err = decodeBase64Field("expected_output", r.ExpectedOutput); err != nil {
+		return err
+	}
+	return nil
+}
+
+// bindSubmissionRequest lee el cuerpo de la petición y lo decodifica si base64_encoded=true
+func bindSubmissionRequest(c *gin.Context, req *CreateSubmissionRequest) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+	if base64Requested(c) {
+		return req.decodeBase64()
+	}
+	return nil
+}
+
+// presentSubmission retorna la submission tal como se envía al cliente:
+// con base64_encoded=true una copia con los textos codificados
+func presentSubmission(c *gin.Context, s *models.Submission) *models.Submission {
+	if s == nil || !base64Requested(c) {
+		return s
+	}
+
+	encoded := *s
+	encoded.SourceCode = encodeBase64Field(s.SourceCode)
+	encoded.Stdin = encodeBase64Field(s.Stdin)
+	encoded.ExpectedOut = encodeBase64Field(s.ExpectedOut)
+	encoded.Stdout = encodeBase64Field(s.Stdout)
+	encoded.Stderr = encodeBase64Field(s.Stderr)
+	encoded.CompileOut = encodeBase64Field(s.CompileOut)
+	return &encoded
+}
+
+// presentSubmissions aplica presentSubmission a una lista
+func presentSubmissions(c *gin.Context, submissions []models.Submission) []models.Submission {
+	if !base64Requested(c) {
+		return submissions
+	}
+
+	encoded := make([]models.Submission, len(submissions))
+	for i := range submissions {
+		encoded[i] = *presentSubmission(c, &submissions[i])
+	}
+	return encoded
+}
+
+// decodeBase64Field decodifica un campo enviado con base64_encoded=true
+func decodeBase64Field(name, value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid base64", name)
+	}
+	return string(decoded), nil
+}
+
+func encodeBase64Field(value string) string {
+	if value == "" {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
@@ -9,19 +9,19 @@ import (
 	"github.com/RobertoRochaT/rojudger/internal/database"
 	"github.com/RobertoRochaT/rojudger/internal/executor"
 	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
 	"github.com/RobertoRochaT/rojudger/internal/webhook"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // Handler maneja las peticiones HTTP (modo directo/sincrono)
 type Handler struct {
-	db       *database.DB
+	db       database.Store
 	executor *executor.Executor
 }
 
 // NewHandler crea una nueva instancia del handler
-func NewHandler(db *database.DB, exec *executor.Executor) *Handler {
+func NewHandler(db database.Store, exec *executor.Executor) *Handler {
 	return &Handler{
 		db:       db,
 		executor: exec,
@@ -31,7 +31,7 @@ func NewHandler(db *database.DB, exec *executor.Executor) *Handler {
 // CreateSubmission maneja POST /submissions (modo directo)
 func (h *Handler) CreateSubmission(c *gin.Context) {
 	var req CreateSubmissionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindSubmissionRequest(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,41 +44,73 @@ func (h *Handler) CreateSubmission(c *gin.Context) {
 		}
 	}
 
+	// La ejecución diferida solo está disponible con cola
+	if req.IsScheduled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "run_at and delay_seconds require queue mode"})
+		return
+	}
+
+	// El tenant solo puede usar sus lenguajes habilitados
+	tenant, ok := requestTenant(c, h.db)
+	if !ok {
+		return
+	}
+	if !languageAllowed(tenant, req.LanguageID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Language not enabled for this tenant"})
+		return
+	}
+
+	// Obtener información del lenguaje antes de reservar el Idempotency-Key y crear la fila
+	language, err := h.db.GetLanguage(req.LanguageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language_id"})
+		return
+	}
+
+	// Reintentos con el mismo Idempotency-Key retornan la submission original
+	submissionID, handled := beginIdempotentRequest(c, h.db, &req, func(*models.Submission) int {
+		return http.StatusOK
+	})
+	if handled {
+		return
+	}
+
 	// Crear submission
 	submission := &models.Submission{
-		ID:          uuid.New().String(),
+		ID:          submissionID,
 		LanguageID:  req.LanguageID,
 		SourceCode:  req.SourceCode,
 		Stdin:       req.Stdin,
 		ExpectedOut: req.ExpectedOutput,
 		WebhookURL:  req.WebhookURL,
+		ProblemID:   req.ProblemID,
+		UserID:      requestUser(c, req.UserID),
+		APIKeyID:    requestOwner(c),
+		TenantID:    requestTenantID(c),
 		Status:      "processing",
 		ExitCode:    -1,
-		CreatedAt:   time.Now(),
+		CreatedAt:   time.Now().UTC(),
 	}
 
 	// Guardar en base de datos
 	if err := h.db.CreateSubmission(submission); err != nil {
 		log.Printf("ERROR creating submission: %v", err)
+		releaseIdempotencyKey(c, h.db)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submission", "details": err.Error()})
 		return
 	}
 
-	// Obtener información del lenguaje
-	language, err := h.db.GetLanguage(req.LanguageID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language_id"})
-		return
-	}
-
-	// Ejecutar código directamente (síncron)
+	// Ejecutar código directamente (síncron), con los techos de recursos del tenant
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
+	if tenant != nil {
+		ctx = executor.WithLimits(ctx, executor.TenantLimits(tenant))
+	}
 
 	result := h.executor.Execute(ctx, submission, language)
 
 	// Actualizar submission con resultados
-	now := time.Now()
+	now := time.Now().UTC()
 	submission.FinishedAt = &now
 	submission.Stdout = result.Stdout
 	submission.Stderr = result.Stderr
@@ -101,8 +133,12 @@ func (h *Handler) CreateSubmission(c *gin.Context) {
 	if err := h.db.UpdateSubmission(submission); err != nil {
 		log.Printf("Failed to update submission: %v", err)
 	}
+	if err := h.db.RecordUsage(models.NewUsageRecord(submission, language, result, now)); err != nil {
+		log.Printf("Failed to record usage: %v", err)
+	}
+	ratelimit.AddCPUUsage(c, submission.Time)
 
-	c.JSON(http.StatusOK, submission)
+	c.JSON(http.StatusOK, presentSubmission(c, submission))
 }
 
 // GetSubmission maneja GET /submissions/:id
@@ -110,38 +146,23 @@ func (h *Handler) GetSubmission(c *gin.Context) {
 	id := c.Param("id")
 
 	submission, err := h.db.GetSubmission(id)
-	if err != nil {
+	if err != nil || !canRead(c, submission) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, submission)
+	c.JSON(http.StatusOK, presentSubmission(c, submission))
 }
 
 // GetSubmissions maneja GET /submissions
 func (h *Handler) GetSubmissions(c *gin.Context) {
-	status := c.Query("status")
-	limit := 100
-
-	if status == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "status query parameter is required"})
-		return
-	}
-
-	submissions, err := h.db.GetSubmissionsByStatus(status, limit)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
-		return
-	}
-
-	c.JSON(http.StatusOK, submissions)
+	listSubmissions(c, h.db)
 }
 
 // GetLanguages maneja GET /languages
 func (h *Handler) GetLanguages(c *gin.Context) {
-	languages, err := h.db.GetAllLanguages()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get languages"})
+	languages, ok := visibleLanguages(c, h.db)
+	if !ok {
 		return
 	}
 
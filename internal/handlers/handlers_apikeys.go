@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/RobertoRochaT/rojudger/internal/auth"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler maneja la administración de API keys
+type APIKeyHandler struct {
+	db database.Store
+}
+
+// NewAPIKeyHandler crea una nueva instancia del handler de API keys
+func NewAPIKeyHandler(db database.Store) *APIKeyHandler {
+	return &APIKeyHandler{db: db}
+}
+
+// CreateAPIKeyRequest representa una petición para crear una API key
+type CreateAPIKeyRequest struct {
+	Name     string   `json:"name" binding:"required,max=100"`
+	Scopes   []string `json:"scopes" binding:"required"`
+	Plan     string   `json:"plan" binding:"max=50"` // plan de rate limiting y cuotas
+	TenantID string   `json:"tenant_id"`             // solo admins globales; "" = key global
+}
+
+// CreateAPIKey maneja POST /api-keys
+// La key en claro solo se retorna en esta respuesta.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Los admins de un tenant solo crean keys de su tenant
+	if tenantID := requestTenantID(c); tenantID != "" {
+		if req.TenantID != "" && req.TenantID != tenantID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot create API keys for another tenant"})
+			return
+		}
+		req.TenantID = tenantID
+	} else if req.TenantID != "" {
+		if _, err := h.db.GetTenant(req.TenantID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tenant_id"})
+			return
+		}
+	}
+
+	key, apiKey, err := auth.GenerateKey(uuid.New().String(), req.Name, req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKey.Plan = req.Plan
+	apiKey.TenantID = req.TenantID
+
+	if err := h.db.CreateAPIKey(apiKey); err != nil {
+		log.Printf("ERROR creating api key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	log.Printf("API key created: %s (%s, tenant: %q, scopes: %v)", apiKey.ID, apiKey.Name, apiKey.TenantID, apiKey.Scopes)
+	c.JSON(http.StatusCreated, gin.H{
+		"key":     key,
+		"api_key": apiKey,
+	})
+}
+
+// ListAPIKeys maneja GET /api-keys (las del tenant del admin, o todas si es global)
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.db.ListAPIKeys(requestTenantID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// RevokeAPIKey maneja DELETE /api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	revoked, err := h.db.RevokeAPIKey(c.Param("id"), requestTenantID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+		return
+	}
+
+	log.Printf("API key revoked: %s", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/RobertoRochaT/rojudger/internal/queue"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/RobertoRochaT/rojudger/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MaxBatchSize es la cantidad máxima de submissions por petición batch
+const MaxBatchSize = 500
+
+// CreateSubmissionsBatch maneja POST /submissions/batch
+// Crea todas las submissions en una transacción y las encola con un pipeline.
+// Los tokens se retornan en el mismo orden que la petición.
+func (h *HandlerWithQueue) CreateSubmissionsBatch(c *gin.Context) {
+	var req CreateBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Submissions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "submissions must not be empty"})
+		return
+	}
+	if len(req.Submissions) > MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("batch size exceeds maximum of %d", MaxBatchSize)})
+		return
+	}
+
+	tenant, ok := requestTenant(c, h.db)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	quotaKey := ratelimit.QuotaKey(c)
+	submissions := make([]*models.Submission, 0, len(req.Submissions))
+
+	// Validar todo antes de insertar: el batch es todo o nada
+	for i := range req.Submissions {
+		item := &req.Submissions[i]
+
+		if base64Requested(c) {
+			if err := item.decodeBase64(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "index": i})
+				return
+			}
+		}
+
+		if err := webhook.ValidateWebhookURL(item.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook URL: " + err.Error(), "index": i})
+			return
+		}
+
+		if !languageAllowed(tenant, item.LanguageID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Language not enabled for this tenant", "index": i})
+			return
+		}
+
+		runAt, err := item.ScheduledTime(now)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "index": i})
+			return
+		}
+
+		submission := &models.Submission{
+			ID:          uuid.New().String(),
+			LanguageID:  item.LanguageID,
+			SourceCode:  item.SourceCode,
+			Stdin:       item.Stdin,
+			ExpectedOut: item.ExpectedOutput,
+			WebhookURL:  item.WebhookURL,
+			ProblemID:   item.ProblemID,
+			UserID:      requestUser(c, item.UserID),
+			APIKeyID:    requestOwner(c),
+			TenantID:    requestTenantID(c),
+			Status:      models.StatusQueued,
+			ExitCode:    -1,
+			CreatedAt:   now,
+			ScheduledAt: runAt,
+			Priority:    clampPriority(item.Priority),
+			QuotaKey:    quotaKey,
+		}
+		if runAt != nil {
+			submission.Status = models.StatusScheduled
+		}
+
+		submissions = append(submissions, submission)
+	}
+
+	if err := h.db.CreateSubmissions(submissions); err != nil {
+		log.Printf("ERROR creating batch: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submissions", "details": err.Error()})
+		return
+	}
+
+	// Encolar las inmediatas en un pipeline; las diferidas van al scheduler
+	ctx := c.Request.Context()
+	jobs := make([]queue.Job, 0, len(submissions))
+	var scheduled []string
+	for _, submission := range submissions {
+		job := queue.Job{SubmissionID: submission.ID, Priority: submission.Priority, LanguageID: submission.LanguageID, QuotaKey: submission.QuotaKey}
+		if submission.ScheduledAt != nil {
+			if err := h.queue.Schedule(ctx, job, *submission.ScheduledAt); err != nil {
+				log.Printf("Failed to schedule submission %s: %v", submission.ID, err)
+				h.failBatch(ctx, submissions, scheduled, "Failed to schedule submission")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule submissions"})
+				return
+			}
+			scheduled = append(scheduled, submission.ID)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := h.queue.EnqueueBatch(ctx, jobs); err != nil {
+		log.Printf("Failed to enqueue batch: %v", err)
+		h.failBatch(ctx, submissions, scheduled, "Failed to enqueue submission")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue submissions"})
+		return
+	}
+
+	responses := make([]models.SubmissionResponse, len(submissions))
+	for i, submission := range submissions {
+		responses[i] = models.SubmissionResponse{
+			ID:     submission.ID,
+			Status: submission.Status,
+			Token:  submission.ID,
+		}
+	}
+
+	log.Printf("Batch of %d submissions created", len(submissions))
+	c.JSON(http.StatusCreated, responses)
+}
+
+// failBatch deshace un batch que se guardó pero no se pudo encolar: retira del
+// scheduler las ya programadas y marca todas como error. El cliente recibe 5xx y
+// no conoce los tokens, así que ninguna debe ejecutarse.
+func (h *HandlerWithQueue) failBatch(ctx context.Context, submissions []*models.Submission, scheduled []string, message string) {
+	for _, id := range scheduled {
+		if _, err := h.queue.CancelScheduled(ctx, id); err != nil {
+			log.Printf("Failed to unschedule submission %s: %v", id, err)
+		}
+	}
+	failSubmissions(h.db, message, submissions...)
+}
+
+// GetSubmissionsBatch maneja GET /submissions/batch?tokens=a,b,c
+func (h *HandlerWithQueue) GetSubmissionsBatch(c *gin.Context) {
+	getSubmissionsBatch(c, h.db)
+}
+
+// GetSubmissionsBatch maneja GET /submissions/batch?tokens=a,b,c
+func (h *Handler) GetSubmissionsBatch(c *gin.Context) {
+	getSubmissionsBatch(c, h.db)
+}
+
+// getSubmissionsBatch retorna las submissions pedidas en el orden de tokens.
+// Los tokens que no existen se retornan como null.
+func getSubmissionsBatch(c *gin.Context, db database.Store) {
+	var tokens []string
+	for _, token := range strings.Split(c.Query("tokens"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tokens query parameter is required"})
+		return
+	}
+	if len(tokens) > MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("cannot request more than %d tokens", MaxBatchSize)})
+		return
+	}
+
+	found, err := db.GetSubmissionsByIDs(tokens)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+		return
+	}
+
+	byID := make(map[string]*models.Submission, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+
+	result := make([]*models.Submission, len(tokens))
+	for i, token := range tokens {
+		// Las ajenas se reportan como inexistentes
+		if submission, ok := byID[token]; ok && canRead(c, submission) {
+			result[i] = presentSubmission(c, submission)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissions": result})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/RobertoRochaT/rojudger/internal/blob"
+	"github.com/gin-gonic/gin"
+)
+
+// BlobHandler sirve las descargas firmadas del blob store local
+type BlobHandler struct {
+	store *blob.FSStore
+}
+
+// NewBlobHandler crea una nueva instancia del handler de blobs
+func NewBlobHandler(store *blob.FSStore) *BlobHandler {
+	return &BlobHandler{store: store}
+}
+
+// DownloadBlob maneja GET /blobs/:key?expires=...&signature=...
+// No requiere autenticación: el link firmado es la autorización.
+func (h *BlobHandler) DownloadBlob(c *gin.Context) {
+	key := c.Param("key")
+	if !h.store.Verify(key, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired link"})
+		return
+	}
+
+	data, err := h.store.Get(c.Request.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Blob not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read blob"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// smokeTestTimeout limita la descarga de la imagen y la ejecución del smoke test
+const smokeTestTimeout = 10 * time.Minute
+
+// LanguageHandler maneja la administración de lenguajes
+type LanguageHandler struct {
+	db   database.Store
+	exec *executor.Executor
+}
+
+// NewLanguageHandler crea una nueva instancia del handler de lenguajes
+func NewLanguageHandler(db database.Store, exec *executor.Executor) *LanguageHandler {
+	return &LanguageHandler{db: db, exec: exec}
+}
+
+// LanguageRequest representa la definición de un lenguaje.
+// El smoke test es un programa cuya salida (sin espacios al inicio y al final)
+// debe ser smoke_test_output para poder habilitar el lenguaje.
+type LanguageRequest struct {
+	ID              int    `json:"id"`                    // solo al crear (IDs de Judge0)
+	Name            string `json:"name" binding:"max=50"` // solo al crear
+	DisplayName     string `json:"display_name" binding:"required,max=100"`
+	Version         string `json:"version" binding:"required,max=50"`
+	Extension       string `json:"extension" binding:"required,max=10"`
+	CompileCmd      string `json:"compile_cmd"`
+	ExecuteCmd      string `json:"execute_cmd" binding:"required"`
+	DockerImage     string `json:"docker_image" binding:"required,max=200"`
+	IsCompiled      bool   `json:"is_compiled"`
+	SmokeTestCode   string `json:"smoke_test_code" binding:"required"`
+	SmokeTestOutput string `json:"smoke_test_output" binding:"required"`
+	Enabled         bool   `json:"enabled"` // solo al crear; después usar /enable y /disable
+}
+
+// apply copia la petición sobre el lenguaje
+func (r *LanguageRequest) apply(lang *models.Language) {
+	lang.DisplayName = r.DisplayName
+	lang.Version = r.Version
+	lang.Extension = r.Extension
+	lang.CompileCmd = r.CompileCmd
+	lang.ExecuteCmd = r.ExecuteCmd
+	lang.DockerImage = r.DockerImage
+	lang.IsCompiled = r.IsCompiled
+	lang.SmokeTestCode = r.SmokeTestCode
+	lang.SmokeTestOutput = r.SmokeTestOutput
+}
+
+// validate revisa lo que el binding no puede
+func (r *LanguageRequest) validate() error {
+	if !strings.Contains(r.ExecuteCmd, "{file}") && !r.IsCompiled {
+		return fmt.Errorf("execute_cmd must reference {file}")
+	}
+	if r.IsCompiled && !strings.Contains(r.CompileCmd, "{file}") {
+		return fmt.Errorf("compile_cmd must reference {file} for compiled languages")
+	}
+	if !strings.HasPrefix(r.Extension, ".") {
+		return fmt.Errorf("extension must start with a dot")
+	}
+	return nil
+}
+
+// ListAllLanguages maneja GET /languages/all (incluye los deshabilitados)
+func (h *LanguageHandler) ListAllLanguages(c *gin.Context) {
+	languages, err := h.db.ListAllLanguages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get languages"})
+		return
+	}
+	c.JSON(http.StatusOK, languages)
+}
+
+// CreateLanguage maneja POST /languages. Con enabled=true el smoke test debe pasar.
+func (h *LanguageHandler) CreateLanguage(c *gin.Context) {
+	var req LanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ID <= 0 || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id and name are required"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.db.GetLanguageAnyState(req.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Language id already exists"})
+		return
+	}
+
+	lang := &models.Language{ID: req.ID, Name: req.Name, IsEnabled: req.Enabled}
+	req.apply(lang)
+
+	rev := &models.LanguageRevision{Action: models.LanguageActionCreate, ChangedBy: requestSubject(c)}
+	if lang.IsEnabled && !h.smokeTest(c, lang, rev) {
+		return
+	}
+
+	if err := h.db.CreateLanguage(lang, rev); err != nil {
+		log.Printf("Failed to create language %d: %v", lang.ID, err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to create language (duplicate id or name?)"})
+		return
+	}
+
+	log.Printf("Language %d (%s) created by %q", lang.ID, lang.Name, rev.ChangedBy)
+	c.JSON(http.StatusCreated, lang)
+}
+
+// UpdateLanguage maneja PUT /languages/:id. Si el lenguaje está habilitado, la
+// nueva definición debe pasar el smoke test antes de guardarse.
+func (h *LanguageHandler) UpdateLanguage(c *gin.Context) {
+	lang, ok := h.loadLanguage(c)
+	if !ok {
+		return
+	}
+
+	var req LanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.ID != 0 && req.ID != lang.ID) || (req.Name != "" && req.Name != lang.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id and name cannot be changed"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.apply(lang)
+	h.save(c, lang, &models.LanguageRevision{Action: models.LanguageActionUpdate, ChangedBy: requestSubject(c)}, lang.IsEnabled)
+}
+
+// EnableLanguage maneja POST /languages/:id/enable (solo si pasa el smoke test)
+func (h *LanguageHandler) EnableLanguage(c *gin.Context) {
+	lang, ok := h.loadLanguage(c)
+	if !ok {
+		return
+	}
+	if lang.IsEnabled {
+		c.JSON(http.StatusOK, lang)
+		return
+	}
+
+	lang.IsEnabled = true
+	h.save(c, lang, &models.LanguageRevision{Action: models.LanguageActionEnable, ChangedBy: requestSubject(c)}, true)
+}
+
+// DisableLanguage maneja POST /languages/:id/disable. Las submissions nuevas se
+// rechazan; las que ya estaban en cola fallan al procesarse.
+func (h *LanguageHandler) DisableLanguage(c *gin.Context) {
+	lang, ok := h.loadLanguage(c)
+	if !ok {
+		return
+	}
+	if !lang.IsEnabled {
+		c.JSON(http.StatusOK, lang)
+		return
+	}
+
+	lang.IsEnabled = false
+	h.save(c, lang, &models.LanguageRevision{Action: models.LanguageActionDisable, ChangedBy: requestSubject(c)}, false)
+}
+
+// SmokeTestLanguage maneja POST /languages/:id/smoke-test (no modifica el lenguaje)
+func (h *LanguageHandler) SmokeTestLanguage(c *gin.Context) {
+	lang, ok := h.loadLanguage(c)
+	if !ok {
+		return
+	}
+	result := runSmokeTest(c.Request.Context(), h.exec, lang)
+	c.JSON(http.StatusOK, gin.H{"language_id": lang.ID, "revision": lang.Revision, "smoke_test": result})
+}
+
+// ListLanguageRevisions maneja GET /languages/:id/revisions
+func (h *LanguageHandler) ListLanguageRevisions(c *gin.Context) {
+	lang, ok := h.loadLanguage(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.db.ListLanguageRevisions(lang.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list language revisions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"language_id": lang.ID, "revision": lang.Revision, "revisions": revisions})
+}
+
+// RestoreLanguageRevision maneja POST /languages/:id/revisions/:revision/restore.
+// Guarda la definición de esa revisión como una revisión nueva.
+func (h *LanguageHandler) RestoreLanguageRevision(c *gin.Context) {
+	lang, ok := h.loadLanguage(c)
+	if !ok {
+		return
+	}
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Language revision not found"})
+		return
+	}
+	old, err := h.db.GetLanguageRevision(lang.ID, revision)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Language revision not found"})
+		return
+	}
+
+	restored := old.Language
+	restored.ID, restored.Name, restored.Revision = lang.ID, lang.Name, lang.Revision
+	h.save(c, &restored, &models.LanguageRevision{Action: models.LanguageActionRestore, ChangedBy: requestSubject(c)}, restored.IsEnabled)
+}
+
+// loadLanguage lee el lenguaje de la ruta (habilitado o no) o responde 404
+func (h *LanguageHandler) loadLanguage(c *gin.Context) (*models.Language, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Language not found"})
+		return nil, false
+	}
+	lang, err := h.db.GetLanguageAnyState(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Language not found"})
+		return nil, false
+	}
+	return lang, true
+}
+
+// save corre el smoke test (si test=true) y guarda el lenguaje como una revisión nueva
+func (h *LanguageHandler) save(c *gin.Context, lang *models.Language, rev *models.LanguageRevision, test bool) {
+	if test && !h.smokeTest(c, lang, rev) {
+		return
+	}
+
+	err := h.db.UpdateLanguage(lang, rev)
+	if errors.Is(err, database.ErrLanguageChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Language was modified by another request, retry"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update language %d: %v", lang.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update language"})
+		return
+	}
+
+	log.Printf("Language %d (%s): %s by %q (revision %d)", lang.ID, lang.Name, rev.Action, rev.ChangedBy, lang.Revision)
+	c.JSON(http.StatusOK, lang)
+}
+
+// smokeTest corre el smoke test y lo adjunta a la revisión. Si no pasa responde
+// 422 con el resultado y retorna false.
+func (h *LanguageHandler) smokeTest(c *gin.Context, lang *models.Language, rev *models.LanguageRevision) bool {
+	result := runSmokeTest(c.Request.Context(), h.exec, lang)
+	rev.SmokeTest = &result
+	if !result.Passed {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Smoke test failed", "smoke_test": result})
+		return false
+	}
+	return true
+}
+
+// runSmokeTest descarga la imagen si hace falta y ejecuta el programa de prueba
+// del lenguaje con los límites normales del executor
+func runSmokeTest(ctx context.Context, exec *executor.Executor, lang *models.Language) models.SmokeTestResult {
+	ctx, cancel := context.WithTimeout(ctx, smokeTestTimeout)
+	defer cancel()
+
+	if err := exec.PullImage(ctx, lang.DockerImage); err != nil {
+		return models.SmokeTestResult{ExitCode: -1, Error: err.Error()}
+	}
+
+	submission := &models.Submission{
+		ID:         fmt.Sprintf("smoke-%d-%d", lang.ID, time.Now().UnixNano()),
+		LanguageID: lang.ID,
+		SourceCode: lang.SmokeTestCode,
+	}
+	execution := exec.Execute(ctx, submission, lang)
+
+	result := models.SmokeTestResult{
+		Stdout:        execution.Stdout,
+		Stderr:        execution.Stderr,
+		CompileOutput: execution.CompileOut,
+		ExitCode:      execution.ExitCode,
+		Time:          execution.Time,
+		Error:         execution.Error,
+	}
+	switch {
+	case execution.Error != "":
+	case execution.TimedOut:
+		result.Error = "smoke test timed out"
+	case execution.ExitCode != 0:
+		result.Error = fmt.Sprintf("smoke test exited with code %d", execution.ExitCode)
+	case strings.TrimSpace(execution.Stdout) != strings.TrimSpace(lang.SmokeTestOutput):
+		result.Error = "smoke test output does not match smoke_test_output"
+	default:
+		result.Passed = true
+	}
+	return result
+}
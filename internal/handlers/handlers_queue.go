@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
@@ -9,20 +10,20 @@ import (
 	"github.com/RobertoRochaT/rojudger/internal/executor"
 	"github.com/RobertoRochaT/rojudger/internal/models"
 	"github.com/RobertoRochaT/rojudger/internal/queue"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
 	"github.com/RobertoRochaT/rojudger/internal/webhook"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-// HandlerWithQueue maneja las peticiones HTTP con cola Redis
+// HandlerWithQueue maneja las peticiones HTTP con cola (Redis o en memoria)
 type HandlerWithQueue struct {
-	db       *database.DB
+	db       database.Store
 	executor *executor.Executor
-	queue    *queue.Queue
+	queue    queue.Queue
 }
 
 // NewHandlerWithQueue crea una nueva instancia del handler con queue
-func NewHandlerWithQueue(db *database.DB, exec *executor.Executor, q *queue.Queue) *HandlerWithQueue {
+func NewHandlerWithQueue(db database.Store, exec *executor.Executor, q queue.Queue) *HandlerWithQueue {
 	return &HandlerWithQueue{
 		db:       db,
 		executor: exec,
@@ -33,7 +34,7 @@ func NewHandlerWithQueue(db *database.DB, exec *executor.Executor, q *queue.Queu
 // CreateSubmissionAsync maneja POST /submissions con cola
 func (h *HandlerWithQueue) CreateSubmissionAsync(c *gin.Context) {
 	var req CreateSubmissionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindSubmissionRequest(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -46,42 +47,124 @@ func (h *HandlerWithQueue) CreateSubmissionAsync(c *gin.Context) {
 		}
 	}
 
-	// Crear submission con status "queued"
+	// El tenant solo puede usar sus lenguajes habilitados
+	tenant, ok := requestTenant(c, h.db)
+	if !ok {
+		return
+	}
+	if !languageAllowed(tenant, req.LanguageID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Language not enabled for this tenant"})
+		return
+	}
+
+	// wait=true bloquea hasta el resultado (máximo wait_timeout segundos)
+	waitRequested := c.Query("wait") == "true"
+	waitTimeout, err := parseWaitTimeout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Calcular ejecución diferida (run_at / delay_seconds)
+	now := time.Now().UTC()
+	runAt, err := req.ScheduledTime(now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Reintentos con el mismo Idempotency-Key retornan la submission original
+	submissionID, handled := beginIdempotentRequest(c, h.db, &req, func(original *models.Submission) int {
+		// Mismo código que la respuesta original: 201 al encolar; con wait=true,
+		// 200 si ya terminó o 202 si sigue en curso
+		if !waitRequested {
+			return http.StatusCreated
+		}
+		if original.IsFinished() {
+			return http.StatusOK
+		}
+		c.Header("Location", "/api/v1/submissions/"+original.ID)
+		return http.StatusAccepted
+	})
+	if handled {
+		return
+	}
+
+	// Obtener y validar prioridad (limitada a [-10, 10])
+	priority := clampPriority(req.Priority)
+	if priority != req.Priority {
+		log.Printf("Priority clamped from %d to %d for submission %s", req.Priority, priority, submissionID)
+	}
+
+	// Crear submission con status "queued" (o "scheduled" si es diferida)
 	submission := &models.Submission{
-		ID:          uuid.New().String(),
+		ID:          submissionID,
 		LanguageID:  req.LanguageID,
 		SourceCode:  req.SourceCode,
 		Stdin:       req.Stdin,
 		ExpectedOut: req.ExpectedOutput,
 		WebhookURL:  req.WebhookURL,
-		Status:      "queued",
+		ProblemID:   req.ProblemID,
+		UserID:      requestUser(c, req.UserID),
+		APIKeyID:    requestOwner(c),
+		TenantID:    requestTenantID(c),
+		Status:      models.StatusQueued,
 		ExitCode:    -1,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+		ScheduledAt: runAt,
+		Priority:    priority,
+		QuotaKey:    ratelimit.QuotaKey(c),
+	}
+	if runAt != nil {
+		submission.Status = models.StatusScheduled
 	}
 
 	// Guardar en base de datos
 	if err := h.db.CreateSubmission(submission); err != nil {
 		log.Printf("ERROR creating submission: %v", err)
+		releaseIdempotencyKey(c, h.db)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submission", "details": err.Error()})
 		return
 	}
 
-	// Obtener y validar prioridad
-	priority := req.Priority
+	job := queue.Job{
+		SubmissionID: submission.ID,
+		Priority:     submission.Priority,
+		LanguageID:   submission.LanguageID,
+		QuotaKey:     submission.QuotaKey,
+	}
 
-	// Validar rango de prioridad (opcionalmente limitar)
-	if priority > 10 {
-		priority = 10 // Máximo permitido
-		log.Printf("Priority capped to 10 for submission %s", submission.ID)
+	// Submissions diferidas van al set de programados; el scheduler las encola después
+	if runAt != nil {
+		if err := h.queue.Schedule(c.Request.Context(), job, *runAt); err != nil {
+			log.Printf("Failed to schedule submission %s: %v", submission.ID, err)
+			failSubmissions(h.db, "Failed to schedule submission", submission)
+			releaseIdempotencyKey(c, h.db)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule submission"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, presentSubmission(c, submission))
+		return
 	}
-	if priority < -10 {
-		priority = -10 // Mínimo permitido
-		log.Printf("Priority floored to -10 for submission %s", submission.ID)
+
+	// Con wait=true nos suscribimos antes de encolar para no perder el evento
+	// de finalización; si falla, waitForResult consulta la base de datos
+	var subscription *queue.Subscription
+	if waitRequested {
+		subscription, err = h.queue.Subscribe(c.Request.Context(), submission.ID)
+		if err != nil {
+			log.Printf("Failed to subscribe to submission %s, falling back to polling: %v", submission.ID, err)
+		} else {
+			defer subscription.Close()
+		}
 	}
 
 	// Encolar para procesamiento asíncrono
-	if err := h.queue.Enqueue(c.Request.Context(), submission.ID, priority); err != nil {
+	if err := h.queue.Enqueue(c.Request.Context(), job); err != nil {
 		log.Printf("Failed to enqueue submission %s: %v", submission.ID, err)
+		failSubmissions(h.db, "Failed to enqueue submission", submission)
+		releaseIdempotencyKey(c, h.db)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue submission"})
 		return
 	}
@@ -96,30 +179,70 @@ func (h *HandlerWithQueue) CreateSubmissionAsync(c *gin.Context) {
 	log.Printf("Submission %s enqueued successfully (priority: %d, queue: %s)", submission.ID, priority, queueName)
 
 	// Verificar si el cliente quiere esperar por el resultado (modo síncrono)
-	if c.Query("wait") == "true" {
-		// Polling: esperar hasta que el trabajo termine
-		timeout := time.After(30 * time.Second)
-		ticker := time.NewTicker(500 * time.Millisecond)
+	if waitRequested {
+		h.waitForResult(c, submission, subscription, waitTimeout)
+		return
+	}
+
+	// Modo asíncrono: retornar inmediatamente
+	h.attachQueueInfo(c.Request.Context(), submission)
+	c.JSON(http.StatusCreated, presentSubmission(c, submission))
+}
+
+// waitForResult espera a que la submission termine y responde con el resultado.
+// Usa el evento pub/sub publicado por el worker; sin suscripción consulta la base
+// de datos periódicamente. Si se agota el tiempo responde 202 con Location.
+func (h *HandlerWithQueue) waitForResult(c *gin.Context, submission *models.Submission, subscription *queue.Subscription, timeout time.Duration) {
+	ctx := c.Request.Context()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	var events <-chan queue.Event
+	var poll <-chan time.Time
+	if subscription != nil {
+		events = subscription.Events()
+	} else {
+		ticker := time.NewTicker(time.Second)
 		defer ticker.Stop()
+		poll = ticker.C
+	}
 
-		for {
-			select {
-			case <-timeout:
-				c.JSON(http.StatusOK, submission) // Retornar con status "queued"
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// La suscripción se cerró: seguir con polling
+				events = nil
+				ticker := time.NewTicker(time.Second)
+				defer ticker.Stop()
+				poll = ticker.C
+				continue
+			}
+			if event.Type != queue.EventResult {
+				continue
+			}
+		case <-poll:
+		case <-deadline.C:
+			// Última revisión por si el evento se perdió
+			if updated, err := h.db.GetSubmission(submission.ID); err == nil && updated.IsFinished() {
+				c.JSON(http.StatusOK, presentSubmission(c, updated))
 				return
-			case <-ticker.C:
-				// Revisar si ya terminó
-				updated, err := h.db.GetSubmission(submission.ID)
-				if err == nil && updated.Status != "queued" && updated.Status != "processing" {
-					c.JSON(http.StatusOK, updated)
-					return
-				}
 			}
+			h.attachQueueInfo(ctx, submission)
+			c.Header("Location", "/api/v1/submissions/"+submission.ID)
+			c.JSON(http.StatusAccepted, presentSubmission(c, submission))
+			return
 		}
-	}
 
-	// Modo asíncrono: retornar inmediatamente
-	c.JSON(http.StatusCreated, submission)
+		// Revisar si ya terminó
+		updated, err := h.db.GetSubmission(submission.ID)
+		if err == nil && updated.IsFinished() {
+			c.JSON(http.StatusOK, presentSubmission(c, updated))
+			return
+		}
+	}
 }
 
 // GetQueueStats retorna estadísticas de la cola
@@ -133,44 +256,129 @@ func (h *HandlerWithQueue) GetQueueStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetWorkers maneja GET /workers
+// Lista los workers vivos (con heartbeat reciente) y lo que están ejecutando
+func (h *HandlerWithQueue) GetWorkers(c *gin.Context) {
+	workers, err := h.queue.ListWorkers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list workers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workers": workers,
+		"summary": queue.SummarizeWorkers(workers),
+	})
+}
+
+// DrainWorker maneja POST /workers/:id/drain
+// El worker deja de tomar trabajos, termina los que tiene en curso y se apaga
+func (h *HandlerWithQueue) DrainWorker(c *gin.Context) {
+	id := c.Param("id")
+
+	found, err := h.queue.RequestDrain(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request drain"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Worker not found"})
+		return
+	}
+
+	log.Printf("Drain requested for worker %s", id)
+	c.JSON(http.StatusAccepted, gin.H{"worker_id": id, "status": "draining"})
+}
+
 // GetSubmission maneja GET /submissions/:id
 func (h *HandlerWithQueue) GetSubmission(c *gin.Context) {
 	id := c.Param("id")
 
 	submission, err := h.db.GetSubmission(id)
-	if err != nil {
+	if err != nil || !canRead(c, submission) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, submission)
+	h.attachQueueInfo(c.Request.Context(), submission)
+	c.JSON(http.StatusOK, presentSubmission(c, submission))
 }
 
 // GetSubmissions maneja GET /submissions
 func (h *HandlerWithQueue) GetSubmissions(c *gin.Context) {
-	status := c.Query("status")
-	limit := 100
+	listSubmissions(c, h.db)
+}
+
+// CancelSubmission maneja POST /submissions/:id/cancel
+// Solo se pueden cancelar submissions programadas que aún no entraron a la cola
+func (h *HandlerWithQueue) CancelSubmission(c *gin.Context) {
+	id := c.Param("id")
+
+	submission, err := h.db.GetSubmission(id)
+	if err != nil || !canRead(c, submission) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	if !canModify(c, submission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can cancel this submission"})
+		return
+	}
 
-	if status == "" {
-		// Podríamos implementar una query general, por ahora retornar error
-		c.JSON(http.StatusBadRequest, gin.H{"error": "status query parameter is required"})
+	if submission.Status != models.StatusScheduled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only scheduled submissions can be cancelled", "status": submission.Status})
 		return
 	}
 
-	submissions, err := h.db.GetSubmissionsByStatus(status, limit)
+	cancelled, err := h.queue.CancelScheduled(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel submission"})
 		return
 	}
+	if !cancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Submission already left the schedule"})
+		return
+	}
+
+	submission.MarkAsCancelled()
+	if err := h.db.UpdateSubmission(submission); err != nil {
+		log.Printf("Failed to update cancelled submission %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update submission"})
+		return
+	}
+
+	// Notificar a los clientes que siguen la submission
+	h.queue.Publish(c.Request.Context(), queue.Event{
+		Type:         queue.EventResult,
+		SubmissionID: id,
+		Status:       submission.Status,
+	})
 
-	c.JSON(http.StatusOK, submissions)
+	c.JSON(http.StatusOK, presentSubmission(c, submission))
+}
+
+// attachQueueInfo agrega la posición en cola y el ETA a submissions en espera
+func (h *HandlerWithQueue) attachQueueInfo(ctx context.Context, submission *models.Submission) {
+	if submission.Status != models.StatusQueued {
+		return
+	}
+
+	position, err := h.queue.Position(ctx, submission.ID)
+	if err != nil {
+		log.Printf("Failed to get queue position for %s: %v", submission.ID, err)
+		return
+	}
+	if position == 0 {
+		return
+	}
+
+	submission.QueuePosition = &position
+	submission.EstimatedStartAt = h.queue.EstimateStart(ctx, position)
 }
 
 // GetLanguages maneja GET /languages
 func (h *HandlerWithQueue) GetLanguages(c *gin.Context) {
-	languages, err := h.db.GetAllLanguages()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get languages"})
+	languages, ok := visibleLanguages(c, h.db)
+	if !ok {
 		return
 	}
 
@@ -195,10 +403,28 @@ func (h *HandlerWithQueue) HealthCheck(c *gin.Context) {
 		queueStatus = "error"
 	}
 
+	// Workers vivos (sin workers la cola no avanza)
+	var workers queue.WorkerSummary
+	if list, err := h.queue.ListWorkers(c.Request.Context()); err == nil {
+		workers = queue.SummarizeWorkers(list)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "healthy",
 		"database":  "ok",
 		"queue":     queueStatus,
+		"workers":   workers,
 		"timestamp": time.Now().Unix(),
 	})
 }
+
+// failSubmissions marca como error submissions que se guardaron pero no se pudieron
+// encolar, para que no queden en queued/scheduled para siempre
+func failSubmissions(db database.Store, message string, submissions ...*models.Submission) {
+	for _, submission := range submissions {
+		submission.MarkAsError(message)
+		if err := db.UpdateSubmission(submission); err != nil {
+			log.Printf("Failed to mark submission %s as error: %v", submission.ID, err)
+		}
+	}
+}
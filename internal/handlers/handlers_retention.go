@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/retention"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRetentionRuns es el máximo de ejecuciones que retorna ListRetentionRuns
+const maxRetentionRuns = 100
+
+// RetentionHandler maneja el janitor de retención
+type RetentionHandler struct {
+	cfg     *config.Config
+	db      database.Store
+	janitor *retention.Janitor
+}
+
+// NewRetentionHandler crea una nueva instancia del handler de retención
+func NewRetentionHandler(cfg *config.Config, db database.Store, janitor *retention.Janitor) *RetentionHandler {
+	return &RetentionHandler{cfg: cfg, db: db, janitor: janitor}
+}
+
+// TriggerRetentionRun maneja POST /retention/runs.
+// La ejecución sigue en segundo plano; su avance se consulta en GET /retention/runs/:id.
+func (h *RetentionHandler) TriggerRetentionRun(c *gin.Context) {
+	run, err := h.janitor.Trigger()
+	if errors.Is(err, retention.ErrRunInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start retention run"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListRetentionRuns maneja GET /retention/runs?limit=N (las más recientes primero)
+func (h *RetentionHandler) ListRetentionRuns(c *gin.Context) {
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxRetentionRuns {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.db.ListRetentionRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list retention runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// GetRetentionRun maneja GET /retention/runs/:id
+func (h *RetentionHandler) GetRetentionRun(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Retention run not found"})
+		return
+	}
+
+	run, err := h.db.GetRetentionRun(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Retention run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetRetentionPolicies maneja GET /retention/policies
+func (h *RetentionHandler) GetRetentionPolicies(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":    h.cfg.RetentionEnabled,
+		"interval":   h.cfg.RetentionInterval.String(),
+		"batch_size": h.cfg.RetentionBatchSize,
+		"archive":    h.cfg.RetentionArchiveDir != "",
+		"policies":   h.janitor.Policies(),
+	})
+}
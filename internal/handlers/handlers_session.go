@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// UserIDHeader etiqueta una sesión interactiva con el usuario final. Es solo
+// informativo: lo envía el cliente, así que no cuenta para los límites.
+const UserIDHeader = "X-User-ID"
+
+// SessionHandler maneja las sesiones interactivas (REPL/playground)
+type SessionHandler struct {
+	db       database.Store
+	sessions *session.Manager
+}
+
+// NewSessionHandler crea una nueva instancia del handler de sesiones
+func NewSessionHandler(db database.Store, sessions *session.Manager) *SessionHandler {
+	return &SessionHandler{
+		db:       db,
+		sessions: sessions,
+	}
+}
+
+// SessionMessage es un mensaje del protocolo WebSocket de sesiones.
+// Cliente → servidor: "input" (data) y "resize" (rows, cols).
+// Servidor → cliente: "started" (session), "output" (data) y "exit" (reason).
+type SessionMessage struct {
+	Type     string        `json:"type"`
+	Data     string        `json:"data,omitempty"`
+	Encoding string        `json:"encoding,omitempty"` // "base64" si la salida no es UTF-8 válido
+	Rows     uint          `json:"rows,omitempty"`
+	Cols     uint          `json:"cols,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Session  *session.Info `json:"session,omitempty"`
+}
+
+// OpenSession maneja GET /sessions/ws?language_id=71 (WebSocket)
+func (h *SessionHandler) OpenSession(c *gin.Context) {
+	languageID, err := strconv.Atoi(c.Query("language_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "language_id is required"})
+		return
+	}
+
+	tenant, ok := requestTenant(c, h.db)
+	if !ok {
+		return
+	}
+
+	language, err := h.db.GetLanguage(languageID)
+	if err != nil || !language.IsEnabled || !languageAllowed(tenant, language.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or disabled language"})
+		return
+	}
+
+	// El contenedor de la sesión respeta los techos de memoria/CPU del tenant
+	ctx := c.Request.Context()
+	if tenant != nil {
+		ctx = executor.WithLimits(ctx, executor.TenantLimits(tenant))
+	}
+
+	// Crear la sesión antes del upgrade para poder responder errores HTTP
+	sess, err := h.sessions.Open(ctx, sessionOwner(c), c.GetHeader(UserIDHeader), language)
+	if errors.Is(err, session.ErrLimitReached) || errors.Is(err, session.ErrUserLimitReached) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open session: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed for session %s: %v", sess.ID(), err)
+		h.sessions.Close(sess.ID(), session.ReasonClosed)
+		return
+	}
+	defer conn.Close()
+
+	// gorilla/websocket admite un solo escritor a la vez
+	var writeMu sync.Mutex
+	send := func(msg SessionMessage) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteJSON(msg)
+	}
+
+	info := sess.Info()
+	if err := send(SessionMessage{Type: "started", Session: &info}); err != nil {
+		h.sessions.Close(sess.ID(), session.ReasonClosed)
+		return
+	}
+
+	// Salida de la terminal → cliente
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := sess.Read(buf)
+			if n > 0 {
+				if send(outputMessage(buf[:n])) != nil {
+					h.sessions.Close(sess.ID(), session.ReasonClosed)
+					return
+				}
+			}
+			if err != nil {
+				h.sessions.Close(sess.ID(), session.ReasonExited)
+				return
+			}
+		}
+	}()
+
+	// Cliente → terminal
+	go func() {
+		for {
+			var msg SessionMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				h.sessions.Close(sess.ID(), session.ReasonClosed)
+				return
+			}
+			switch msg.Type {
+			case "input":
+				if _, err := sess.Write([]byte(msg.Data)); err != nil {
+					h.sessions.Close(sess.ID(), session.ReasonExited)
+					return
+				}
+			case "resize":
+				if msg.Rows > 0 && msg.Cols > 0 {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					sess.Resize(ctx, msg.Rows, msg.Cols)
+					cancel()
+				}
+			}
+		}
+	}()
+
+	<-sess.Done()
+	send(SessionMessage{Type: "exit", Reason: sess.Reason()})
+
+	writeMu.Lock()
+	conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, sess.Reason()))
+	writeMu.Unlock()
+}
+
+// ListSessions maneja GET /sessions
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	sessions := h.sessions.List()
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// TerminateSession maneja DELETE /sessions/:id (cierre forzado)
+func (h *SessionHandler) TerminateSession(c *gin.Context) {
+	if !h.sessions.Close(c.Param("id"), session.ReasonTerminated) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// sessionOwner identifica al dueño de la sesión para SESSION_MAX_PER_USER: la API
+// key o el usuario del JWT autenticados, o la IP del cliente sin autenticación
+func sessionOwner(c *gin.Context) string {
+	if subject := requestSubject(c); subject != "" {
+		return subject
+	}
+	return c.ClientIP()
+}
+
+// outputMessage arma un mensaje "output". Los bytes que no son UTF-8 válido se
+// envían en base64 para que JSON no los reemplace por U+FFFD.
+func outputMessage(data []byte) SessionMessage {
+	if !utf8.Valid(data) {
+		return SessionMessage{Type: "output", Data: base64.StdEncoding.EncodeToString(data), Encoding: "base64"}
+	}
+	return SessionMessage{Type: "output", Data: string(data)}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/RobertoRochaT/rojudger/internal/queue"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// positionInterval es cada cuánto se recalcula la posición en cola
+	positionInterval = 2 * time.Second
+	// keepAliveInterval mantiene viva la conexión a través de proxies
+	keepAliveInterval = 15 * time.Second
+)
+
+// Nombres de los eventos enviados a los clientes
+const (
+	streamEventStatus   = "status"
+	streamEventPosition = "position"
+	streamEventResult   = "result"
+	streamEventOutput   = "output"
+	streamEventPing     = "ping"
+)
+
+// StatusUpdate es el payload de los eventos status y position
+type StatusUpdate struct {
+	SubmissionID     string     `json:"submission_id"`
+	Status           string     `json:"status"`
+	QueuePosition    *int64     `json:"queue_position,omitempty"`
+	EstimatedStartAt *time.Time `json:"estimated_start_at,omitempty"`
+}
+
+// OutputChunk es el payload de los eventos output (salida en vivo)
+type OutputChunk struct {
+	SubmissionID string `json:"submission_id"`
+	Stream       string `json:"stream"`
+	Data         string `json:"data"`
+	Encoding     string `json:"encoding,omitempty"` // "base64" para salida binaria o con base64_encoded=true
+	Seq          int64  `json:"seq"`
+}
+
+// emitFunc envía un evento al cliente (SSE o WebSocket)
+type emitFunc func(event string, data interface{}) error
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Igual que corsMiddleware: se aceptan todos los orígenes
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// StreamSubmissionEvents maneja GET /submissions/:id/events (Server-Sent Events)
+func (h *HandlerWithQueue) StreamSubmissionEvents(c *gin.Context) {
+	id := c.Param("id")
+	if submission, err := h.db.GetSubmission(id); err != nil || !canRead(c, submission) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	emit := func(event string, data interface{}) error {
+		if event == streamEventPing {
+			_, err := io.WriteString(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+			return err
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		return nil
+	}
+
+	if err := h.streamSubmission(c.Request.Context(), c, id, emit); err != nil {
+		log.Printf("SSE stream for %s ended: %v", id, err)
+	}
+}
+
+// StreamSubmissionWS maneja GET /submissions/:id/ws (WebSocket)
+// Cada mensaje es un JSON {"event": "...", "data": {...}}
+func (h *HandlerWithQueue) StreamSubmissionWS(c *gin.Context) {
+	id := c.Param("id")
+	if submission, err := h.db.GetSubmission(id); err != nil || !canRead(c, submission) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed for %s: %v", id, err)
+		return
+	}
+	defer conn.Close()
+
+	// Detectar cuando el cliente cierra la conexión
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	emit := func(event string, data interface{}) error {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if event == streamEventPing {
+			return conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		return conn.WriteJSON(gin.H{"event": event, "data": data})
+	}
+
+	if err := h.streamSubmission(ctx, c, id, emit); err != nil {
+		log.Printf("WebSocket stream for %s ended: %v", id, err)
+		return
+	}
+
+	conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "submission finished"))
+}
+
+// streamSubmission emite el estado actual de la submission y luego sus cambios
+// (estado, posición en cola y resultado final) hasta que termine o se cancele ctx.
+// El resultado se presenta como en GET /submissions/:id (base64_encoded de c).
+func (h *HandlerWithQueue) streamSubmission(ctx context.Context, c *gin.Context, id string, emit emitFunc) error {
+	// Suscribirse antes de leer el estado para no perder eventos intermedios
+	subscription, err := h.queue.Subscribe(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	submission, err := h.db.GetSubmission(id)
+	if err != nil {
+		return err
+	}
+	if submission.IsFinished() {
+		return emit(streamEventResult, presentSubmission(c, submission))
+	}
+
+	current := h.statusUpdate(ctx, submission.ID, submission.Status)
+	if err := emit(streamEventStatus, current); err != nil {
+		return err
+	}
+
+	// Reenviar la salida producida antes de conectarse
+	var lastSeq int64
+	if submission.Status == models.StatusProcessing {
+		history, err := h.queue.OutputHistory(ctx, id)
+		if err != nil {
+			log.Printf("Failed to get output history for %s: %v", id, err)
+		}
+		for _, event := range history {
+			if err := emit(streamEventOutput, outputChunk(c, event)); err != nil {
+				return err
+			}
+			lastSeq = event.Seq
+		}
+	}
+
+	positionTicker := time.NewTicker(positionInterval)
+	defer positionTicker.Stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return fmt.Errorf("subscription closed")
+			}
+			switch event.Type {
+			case queue.EventStatus:
+				// Una submission reencolada vuelve a numerar su salida
+				if event.Status == models.StatusQueued {
+					lastSeq = 0
+				}
+				current = h.statusUpdate(ctx, id, event.Status)
+				if err := emit(streamEventStatus, current); err != nil {
+					return err
+				}
+			case queue.EventOutput:
+				// Ignorar fragmentos ya enviados desde el historial
+				if event.Seq <= lastSeq {
+					continue
+				}
+				lastSeq = event.Seq
+				if err := emit(streamEventOutput, outputChunk(c, event)); err != nil {
+					return err
+				}
+			case queue.EventResult:
+				final, err := h.db.GetSubmission(id)
+				if err != nil {
+					return err
+				}
+				return emit(streamEventResult, presentSubmission(c, final))
+			}
+
+		case <-positionTicker.C:
+			if current.Status != models.StatusQueued {
+				continue
+			}
+			update := h.statusUpdate(ctx, id, current.Status)
+			if positionChanged(current.QueuePosition, update.QueuePosition) {
+				current = update
+				if err := emit(streamEventPosition, current); err != nil {
+					return err
+				}
+			}
+
+		case <-keepAlive.C:
+			if err := emit(streamEventPing, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// statusUpdate arma el payload de estado, con posición y ETA si está en cola
+func (h *HandlerWithQueue) statusUpdate(ctx context.Context, id, status string) StatusUpdate {
+	update := StatusUpdate{SubmissionID: id, Status: status}
+	if status != models.StatusQueued {
+		return update
+	}
+
+	position, err := h.queue.Position(ctx, id)
+	if err != nil || position == 0 {
+		return update
+	}
+	update.QueuePosition = &position
+	update.EstimatedStartAt = h.queue.EstimateStart(ctx, position)
+	return update
+}
+
+// outputChunk convierte un evento de salida en el payload enviado al cliente
+func outputChunk(c *gin.Context, event queue.Event) OutputChunk {
+	chunk := OutputChunk{
+		SubmissionID: event.SubmissionID,
+		Stream:       event.Stream,
+		Data:         event.Data,
+		Encoding:     event.Encoding,
+		Seq:          event.Seq,
+	}
+	if chunk.Encoding == "" && base64Requested(c) {
+		chunk.Data = encodeBase64Field(chunk.Data)
+		chunk.Encoding = queue.EncodingBase64
+	}
+	return chunk
+}
+
+// positionChanged compara dos posiciones opcionales
+func positionChanged(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a != b
+	}
+	return *a != *b
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHandler maneja la administración de tenants
+type TenantHandler struct {
+	db database.Store
+}
+
+// NewTenantHandler crea una nueva instancia del handler de tenants
+func NewTenantHandler(db database.Store) *TenantHandler {
+	return &TenantHandler{db: db}
+}
+
+// TenantRequest representa la configuración de un tenant.
+// Los límites en 0 significan "sin techo propio" (aplica la configuración global).
+type TenantRequest struct {
+	ID               string  `json:"id" binding:"max=64"` // solo al crear
+	Name             string  `json:"name" binding:"required,max=100"`
+	EnabledLanguages []int   `json:"enabled_languages"` // vacío = todos
+	MaxTimeLimit     float64 `json:"max_time_limit" binding:"gte=0"`
+	MaxMemoryMB      int     `json:"max_memory_mb" binding:"gte=0"`
+	MaxCPUs          float64 `json:"max_cpus" binding:"gte=0"`
+	WebhookSecret    *string `json:"webhook_secret"`        // nil = conservar el actual
+	Plan             string  `json:"plan" binding:"max=50"` // plan de cuotas ("" = default)
+}
+
+// tenantView agrega a la respuesta si hay secreto de webhooks, sin exponerlo
+type tenantView struct {
+	*models.Tenant
+	WebhookSecretSet bool `json:"webhook_secret_set"`
+}
+
+func presentTenant(tenant *models.Tenant) tenantView {
+	return tenantView{Tenant: tenant, WebhookSecretSet: tenant.WebhookSecret != ""}
+}
+
+// apply copia la petición sobre el tenant
+func (r *TenantRequest) apply(tenant *models.Tenant) {
+	tenant.Name = r.Name
+	tenant.EnabledLanguages = r.EnabledLanguages
+	tenant.MaxTimeLimit = r.MaxTimeLimit
+	tenant.MaxMemoryMB = r.MaxMemoryMB
+	tenant.MaxCPUs = r.MaxCPUs
+	tenant.Plan = r.Plan
+	if r.WebhookSecret != nil {
+		tenant.WebhookSecret = *r.WebhookSecret
+	}
+}
+
+// validLanguages verifica que todos los lenguajes habilitados existan
+func (h *TenantHandler) validLanguages(c *gin.Context, ids []int) bool {
+	for _, id := range ids {
+		if _, err := h.db.GetLanguage(id); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown language_id in enabled_languages", "language_id": id})
+			return false
+		}
+	}
+	return true
+}
+
+// CreateTenant maneja POST /tenants
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req TenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	if !h.validLanguages(c, req.EnabledLanguages) {
+		return
+	}
+
+	if _, err := h.db.GetTenant(req.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tenant already exists"})
+		return
+	}
+
+	tenant := &models.Tenant{ID: req.ID}
+	req.apply(tenant)
+
+	if err := h.db.CreateTenant(tenant); err != nil {
+		log.Printf("ERROR creating tenant: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tenant"})
+		return
+	}
+
+	log.Printf("Tenant created: %s (%s)", tenant.ID, tenant.Name)
+	c.JSON(http.StatusCreated, presentTenant(tenant))
+}
+
+// ListTenants maneja GET /tenants
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	tenants, err := h.db.ListTenants()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tenants"})
+		return
+	}
+
+	views := make([]tenantView, len(tenants))
+	for i := range tenants {
+		views[i] = presentTenant(&tenants[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"tenants": views})
+}
+
+// GetTenant maneja GET /tenants/:id
+// Los admins de un tenant solo pueden ver el suyo.
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	id := c.Param("id")
+	if tenantID := requestTenantID(c); tenantID != "" && tenantID != id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	tenant, err := h.db.GetTenant(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, presentTenant(tenant))
+}
+
+// UpdateTenant maneja PUT /tenants/:id
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	var req TenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validLanguages(c, req.EnabledLanguages) {
+		return
+	}
+
+	tenant, err := h.db.GetTenant(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+		return
+	}
+	req.apply(tenant)
+
+	updated, err := h.db.UpdateTenant(tenant)
+	if err != nil {
+		log.Printf("ERROR updating tenant: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tenant"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	log.Printf("Tenant updated: %s", tenant.ID)
+	c.JSON(http.StatusOK, presentTenant(tenant))
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUsageRangeDays es el rango máximo de días de una consulta de consumo
+const maxUsageRangeDays = 366
+
+// UsageHandler expone el consumo medido para facturación
+type UsageHandler struct {
+	db database.Store
+}
+
+// NewUsageHandler crea una nueva instancia del handler de consumo
+func NewUsageHandler(db database.Store) *UsageHandler {
+	return &UsageHandler{db: db}
+}
+
+// GetUsage maneja GET /usage?from=YYYY-MM-DD&to=YYYY-MM-DD[&tenant_id=][&api_key_id=][&format=csv]
+// El rango es inclusivo y por defecto es el mes en curso (UTC). Los admins de un
+// tenant solo ven el consumo de su tenant.
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	from, to, err := usageRange(c.Query("from"), c.Query("to"), time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.UsageQuery{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		TenantID: c.Query("tenant_id"),
+		APIKeyID: c.Query("api_key_id"),
+	}
+	if tenantID := requestTenantID(c); tenantID != "" {
+		if query.TenantID != "" && query.TenantID != tenantID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot read usage of another tenant"})
+			return
+		}
+		query.TenantID = tenantID
+	}
+
+	usage, err := h.db.SummarizeUsage(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage"})
+		return
+	}
+
+	switch c.Query("format") {
+	case "", "json":
+		c.JSON(http.StatusOK, gin.H{
+			"from":   from.Format("2006-01-02"),
+			"to":     to.Format("2006-01-02"),
+			"usage":  usage,
+			"totals": usageTotals(usage),
+		})
+	case "csv":
+		writeUsageCSV(c, usage, fmt.Sprintf("usage_%s_%s.csv", from.Format("20060102"), to.Format("20060102")))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+	}
+}
+
+// usageRange interpreta from/to (fechas inclusivas en UTC)
+func usageRange(fromParam, toParam string, now time.Time) (from, to time.Time, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to = today
+
+	if fromParam != "" {
+		if from, err = time.Parse("2006-01-02", fromParam); err != nil {
+			return from, to, fmt.Errorf("from must be a date (YYYY-MM-DD)")
+		}
+	}
+	if toParam != "" {
+		if to, err = time.Parse("2006-01-02", toParam); err != nil {
+			return from, to, fmt.Errorf("to must be a date (YYYY-MM-DD)")
+		}
+	}
+
+	if to.Before(from) {
+		return from, to, fmt.Errorf("to must not be before from")
+	}
+	if to.Sub(from) >= maxUsageRangeDays*24*time.Hour {
+		return from, to, fmt.Errorf("date range must not exceed %d days", maxUsageRangeDays)
+	}
+	return from, to, nil
+}
+
+// usageTotals suma todas las filas del resumen
+func usageTotals(usage []models.UsageSummary) models.UsageSummary {
+	var totals models.UsageSummary
+	for _, row := range usage {
+		totals.Submissions += row.Submissions
+		totals.CPUSeconds += row.CPUSeconds
+		totals.WallSeconds += row.WallSeconds
+		totals.MemoryMBSeconds += row.MemoryMBSeconds
+		totals.CompileSeconds += row.CompileSeconds
+	}
+	return totals
+}
+
+// writeUsageCSV responde el resumen como archivo CSV descargable
+func writeUsageCSV(c *gin.Context, usage []models.UsageSummary, filename string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	formatSeconds := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 3, 64)
+	}
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"day", "tenant_id", "api_key_id", "submissions", "cpu_seconds", "wall_seconds", "memory_mb_seconds", "compile_seconds"})
+	for _, row := range usage {
+		w.Write([]string{
+			row.Day,
+			row.TenantID,
+			row.APIKeyID,
+			strconv.FormatInt(row.Submissions, 10),
+			formatSeconds(row.CPUSeconds),
+			formatSeconds(row.WallSeconds),
+			formatSeconds(row.MemoryMBSeconds),
+			formatSeconds(row.CompileSeconds),
+		})
+	}
+	w.Flush()
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyHeader es el header que envían los clientes para reintentos seguros
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader indica que la respuesta corresponde a una petición previa
+	IdempotentReplayHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// requestFingerprint calcula un hash del contenido de la petición
+func requestFingerprint(req interface{}) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// beginIdempotentRequest reserva el Idempotency-Key (si se envió) y retorna el ID
+// que debe usar la nueva submission. Si la petición es un reintento, escribe la
+// respuesta original con el código que da replayStatus y retorna handled = true.
+func beginIdempotentRequest(c *gin.Context, db database.Store, req *CreateSubmissionRequest, replayStatus func(original *models.Submission) int) (submissionID string, handled bool) {
+	submissionID = uuid.New().String()
+
+	key := c.GetHeader(IdempotencyHeader)
+	if key == "" {
+		return submissionID, false
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+		return "", true
+	}
+
+	fingerprint := requestFingerprint(req)
+	existing, reserved, err := db.ReserveIdempotencyKey(storedIdempotencyKey(c, key), fingerprint, submissionID)
+	if err != nil {
+		log.Printf("ERROR reserving idempotency key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+		return "", true
+	}
+	if reserved {
+		return submissionID, false
+	}
+
+	// El key ya se usó: validar que sea la misma petición
+	if existing.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return "", true
+	}
+
+	submission, err := db.GetSubmission(existing.SubmissionID)
+	if err != nil {
+		// La petición original todavía no termina de crear la submission
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+		return "", true
+	}
+
+	log.Printf("Idempotent replay for submission %s", submission.ID)
+	c.Header(IdempotentReplayHeader, "true")
+	c.JSON(replayStatus(submission), presentSubmission(c, submission))
+	return "", true
+}
+
+// releaseIdempotencyKey libera el key cuando la creación falla, para permitir reintentos
+func releaseIdempotencyKey(c *gin.Context, db database.Store) {
+	key := c.GetHeader(IdempotencyHeader)
+	if key == "" {
+		return
+	}
+	if err := db.DeleteIdempotencyKey(storedIdempotencyKey(c, key)); err != nil {
+		log.Printf("Failed to release idempotency key: %v", err)
+	}
+}
+
+// storedIdempotencyKey separa los keys de cada API key (o usuario del JWT) para que
+// dos clientes que usen el mismo valor no compartan submissions
+func storedIdempotencyKey(c *gin.Context, key string) string {
+	owner := requestSubject(c)
+	if owner == "" {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return owner + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Campos que retorna Judge0 cuando no se indica fields=
+const judge0DefaultFields = "token,stdout,stderr,compile_output,message,status,time,memory"
+
+// judge0Fields son todos los campos soportados por fields= (fields=* los retorna todos)
+var judge0Fields = []string{
+	"token", "source_code", "language_id", "language", "stdin", "expected_output",
+	"stdout", "stderr", "compile_output", "message", "exit_code", "status", "status_id",
+	"time", "wall_time", "memory", "created_at", "finished_at", "callback_url",
+}
+
+// Judge0Handler traduce peticiones y respuestas con el formato de Judge0
+// sobre los handlers nativos, para que los clientes de Judge0 funcionen sin cambios
+type Judge0Handler struct {
+	db          database.Store
+	config      *config.Config
+	version     string
+	create      gin.HandlerFunc // POST /submissions nativo
+	createBatch gin.HandlerFunc // POST /submissions/batch nativo (nil si no está disponible)
+}
+
+// NewJudge0Handler crea el handler de compatibilidad con Judge0
+func NewJudge0Handler(db database.Store, cfg *config.Config, version string, create, createBatch gin.HandlerFunc) *Judge0Handler {
+	return &Judge0Handler{
+		db:          db,
+		config:      cfg,
+		version:     version,
+		create:      create,
+		createBatch: createBatch,
+	}
+}
+
+// Judge0SubmissionRequest es el cuerpo de una submission de Judge0.
+// Los límites por submission (cpu_time_limit, memory_limit, etc.) se aceptan pero se ignoran.
+type Judge0SubmissionRequest struct {
+	SourceCode     string `json:"source_code"`
+	LanguageID     int    `json:"language_id"`
+	Stdin          string `json:"stdin"`
+	ExpectedOutput string `json:"expected_output"`
+	CallbackURL    string `json:"callback_url"`
+}
+
+// Judge0BatchRequest es el cuerpo de POST /submissions/batch
+type Judge0BatchRequest struct {
+	Submissions []Judge0SubmissionRequest `json:"submissions"`
+}
+
+// CreateSubmission maneja POST /submissions?base64_encoded=&wait=&fields=
+func (h *Judge0Handler) CreateSubmission(c *gin.Context) {
+	base64Encoded := c.Query("base64_encoded") == "true"
+	fields, err := judge0ParseFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req Judge0SubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, body := callNative(c, h.create, req.toNative())
+	if !judge0Success(status) {
+		judge0Error(c, status, body)
+		return
+	}
+
+	var created models.SubmissionResponse
+	if err := json.Unmarshal(body, &created); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submission"})
+		return
+	}
+
+	// Los clientes de Judge0 no conocen el Location de la API nativa
+	c.Writer.Header().Del("Location")
+
+	if c.Query("wait") != "true" {
+		c.JSON(http.StatusCreated, gin.H{"token": created.ID})
+		return
+	}
+
+	// Releer la submission: la respuesta nativa ya viene codificada si base64_encoded=true
+	submission, err := h.db.GetSubmission(created.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submission"})
+		return
+	}
+	c.JSON(http.StatusCreated, judge0Select(judge0View(submission, base64Encoded), fields))
+}
+
+// CreateSubmissionsBatch maneja POST /submissions/batch?base64_encoded=
+func (h *Judge0Handler) CreateSubmissionsBatch(c *gin.Context) {
+	if h.createBatch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "batched submissions are not allowed"})
+		return
+	}
+
+	var req Judge0BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	native := CreateBatchRequest{Submissions: make([]CreateSubmissionRequest, 0, len(req.Submissions))}
+	for i := range req.Submissions {
+		native.Submissions = append(native.Submissions, req.Submissions[i].toNative())
+	}
+
+	status, body := callNative(c, h.createBatch, native)
+	if !judge0Success(status) {
+		judge0Error(c, status, body)
+		return
+	}
+
+	var created []models.SubmissionResponse
+	if err := json.Unmarshal(body, &created); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submissions"})
+		return
+	}
+
+	tokens := make([]gin.H, 0, len(created))
+	for _, item := range created {
+		tokens = append(tokens, gin.H{"token": item.ID})
+	}
+	c.JSON(http.StatusCreated, tokens)
+}
+
+// GetSubmission maneja GET /submissions/:token?base64_encoded=&fields=
+func (h *Judge0Handler) GetSubmission(c *gin.Context) {
+	fields, err := judge0ParseFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	submission, err := h.db.GetSubmission(c.Param("token"))
+	if err != nil || !canRead(c, submission) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	base64Encoded := c.Query("base64_encoded") == "true"
+	if !base64Encoded && !submission.IsValidUTF8() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "some attributes for this submission cannot be converted to UTF-8, use base64_encoded=true query parameter",
+		})
+		return
+	}
+
+	view := judge0View(submission, base64Encoded)
+	c.JSON(http.StatusOK, judge0Select(view, fields))
+}
+
+// GetSubmissionsBatch maneja GET /submissions/batch?tokens=a,b,c&base64_encoded=&fields=
+func (h *Judge0Handler) GetSubmissionsBatch(c *gin.Context) {
+	fields, err := judge0ParseFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(c.Query("tokens"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tokens query parameter is required"})
+		return
+	}
+	if len(tokens) > MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("number of tokens exceeds maximum of %d", MaxBatchSize)})
+		return
+	}
+
+	found, err := h.db.GetSubmissionsByIDs(tokens)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+		return
+	}
+
+	byID := make(map[string]*models.Submission, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+
+	base64Encoded := c.Query("base64_encoded") == "true"
+	results := make([]gin.H, len(tokens))
+	for i, token := range tokens {
+		if submission, ok := byID[token]; ok && canRead(c, submission) {
+			results[i] = judge0Select(judge0View(submission, base64Encoded), fields)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"submissions": results})
+}
+
+// GetSubmissions maneja GET /submissions?page=&per_page=&base64_encoded=&fields=
+func (h *Judge0Handler) GetSubmissions(c *gin.Context) {
+	fields, err := judge0ParseFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page := judge0IntQuery(c, "page", 1)
+	perPage := judge0IntQuery(c, "per_page", 20)
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	scope := readFilter(c)
+	total, err := h.db.CountSubmissions(scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+		return
+	}
+	submissions, err := h.db.ListSubmissions(scope, (page-1)*perPage, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+		return
+	}
+
+	base64Encoded := c.Query("base64_encoded") == "true"
+	results := make([]gin.H, 0, len(submissions))
+	for i := range submissions {
+		results = append(results, judge0Select(judge0View(&submissions[i], base64Encoded), fields))
+	}
+
+	totalPages := (total + perPage - 1) / perPage
+	meta := gin.H{
+		"current_page": page,
+		"next_page":    nil,
+		"prev_page":    nil,
+		"total_pages":  totalPages,
+		"total_count":  total,
+	}
+	if page < totalPages {
+		meta["next_page"] = page + 1
+	}
+	if page > 1 {
+		meta["prev_page"] = page - 1
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissions": results, "meta": meta})
+}
+
+// GetStatuses maneja GET /statuses
+func (h *Judge0Handler) GetStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, models.Judge0Statuses)
+}
+
+// GetLanguages maneja GET /languages
+func (h *Judge0Handler) GetLanguages(c *gin.Context) {
+	languages, ok := visibleLanguages(c, h.db)
+	if !ok {
+		return
+	}
+
+	result := make([]gin.H, 0, len(languages))
+	for i := range languages {
+		result = append(result, gin.H{
+			"id":   languages[i].ID,
+			"name": judge0LanguageName(&languages[i]),
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetLanguage maneja GET /languages/:id
+func (h *Judge0Handler) GetLanguage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	tenant, ok := requestTenant(c, h.db)
+	if !ok {
+		return
+	}
+	language, err := h.db.GetLanguage(id)
+	if err != nil || !languageAllowed(tenant, language.ID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":          language.ID,
+		"name":        judge0LanguageName(language),
+		"is_archived": !language.IsEnabled,
+		"source_file": "main" + language.Extension,
+		"compile_cmd": nullIfEmpty(strings.ReplaceAll(language.CompileCmd, "{file}", "main"+language.Extension)),
+		"run_cmd":     strings.ReplaceAll(language.ExecuteCmd, "{file}", "main"+language.Extension),
+	})
+}
+
+// GetAbout maneja GET /about
+func (h *Judge0Handler) GetAbout(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":     h.version,
+		"homepage":    "https://github.com/RobertoRochaT/rojudger",
+		"source_code": "https://github.com/RobertoRochaT/rojudger",
+		"maintainer":  "ROJUDGER",
+	})
+}
+
+// GetConfigInfo maneja GET /config_info
+func (h *Judge0Handler) GetConfigInfo(c *gin.Context) {
+	timeLimit := h.config.ExecutorTimeout.Seconds()
+	memoryLimit := parseMemoryLimitKB(h.config.ExecutorMemoryLimit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"enable_wait_result":                         true,
+		"enable_compiler_options":                    false,
+		"allowed_languages_for_compile_options":      []string{},
+		"enable_command_line_arguments":              false,
+		"enable_submission_delete":                   false,
+		"enable_callbacks":                           true,
+		"callbacks_max_tries":                        3,
+		"callbacks_timeout":                          30,
+		"enable_additional_files":                    false,
+		"max_queue_size":                             nil,
+		"cpu_time_limit":                             timeLimit,
+		"max_cpu_time_limit":                         timeLimit,
+		"cpu_extra_time":                             0,
+		"max_cpu_extra_time":                         0,
+		"wall_time_limit":                            timeLimit,
+		"max_wall_time_limit":                        timeLimit,
+		"memory_limit":                               memoryLimit,
+		"max_memory_limit":                           memoryLimit,
+		"enable_per_process_and_thread_time_limit":   false,
+		"enable_per_process_and_thread_memory_limit": false,
+		"number_of_runs":                             1,
+		"max_number_of_runs":                         1,
+		"redirect_stderr_to_stdout":                  false,
+		"enable_network":                             false,
+		"enable_batched_submissions":                 h.createBatch != nil,
+		"max_submission_batch_size":                  MaxBatchSize,
+	})
+}
+
+// toNative convierte la petición de Judge0 en una petición nativa.
+// Los campos en base64 los decodifica el handler nativo (lee el mismo base64_encoded).
+func (r *Judge0SubmissionRequest) toNative() CreateSubmissionRequest {
+	return CreateSubmissionRequest{
+		LanguageID:     r.LanguageID,
+		SourceCode:     r.SourceCode,
+		Stdin:          r.Stdin,
+		ExpectedOutput: r.ExpectedOutput,
+		WebhookURL:     r.CallbackURL,
+	}
+}
+
+// judge0View arma la representación de Judge0 de una submission con todos los campos
+func judge0View(s *models.Submission, base64Encoded bool) gin.H {
+	text := func(value string) interface{} {
+		if value == "" {
+			return nil
+		}
+		if base64Encoded {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		return value
+	}
+
+	status := s.Judge0Status()
+	view := gin.H{
+		"token":           s.ID,
+		"source_code":     text(s.SourceCode),
+		"language_id":     s.LanguageID,
+		"language":        gin.H{"id": s.LanguageID},
+		"stdin":           text(s.Stdin),
+		"expected_output": text(s.ExpectedOut),
+		"stdout":          text(s.Stdout),
+		"stderr":          text(s.Stderr),
+		"compile_output":  text(s.CompileOut),
+		"message":         text(s.Message),
+		"exit_code":       nil,
+		"status":          status,
+		"status_id":       status.ID,
+		"time":            nil,
+		"wall_time":       nil,
+		"memory":          nil,
+		"created_at":      s.CreatedAt,
+		"finished_at":     s.FinishedAt,
+		"callback_url":    nullIfEmpty(s.WebhookURL),
+	}
+
+	// Judge0 retorna null en los resultados mientras no termina
+	if s.IsFinished() {
+		execTime := strconv.FormatFloat(s.Time, 'f', 3, 64)
+		view["exit_code"] = s.ExitCode
+		view["time"] = execTime
+		view["wall_time"] = execTime
+		view["memory"] = s.Memory
+	}
+	return view
+}
+
+// judge0ParseFields lee el query param fields= (por defecto los campos de Judge0)
+func judge0ParseFields(c *gin.Context) ([]string, error) {
+	value := c.DefaultQuery("fields", judge0DefaultFields)
+	if value == "*" {
+		return judge0Fields, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !containsString(judge0Fields, field) {
+			return nil, fmt.Errorf("invalid field: %s", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// judge0Select retorna solo los campos pedidos
+func judge0Select(view gin.H, fields []string) gin.H {
+	selected := make(gin.H, len(fields))
+	for _, field := range fields {
+		selected[field] = view[field]
+	}
+	return selected
+}
+
+// judge0Success indica si el handler nativo respondió con éxito
+func judge0Success(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// judge0Error reenvía un error del handler nativo (Judge0 usa 422 para validaciones)
+func judge0Error(c *gin.Context, status int, body []byte) {
+	if status == http.StatusBadRequest {
+		status = http.StatusUnprocessableEntity
+	}
+	c.Data(status, "application/json; charset=utf-8", body)
+}
+
+// judge0LanguageName arma el nombre al estilo Judge0, p.ej. "Python 3 (3.11)"
+func judge0LanguageName(language *models.Language) string {
+	return fmt.Sprintf("%s (%s)", language.DisplayName, language.Version)
+}
+
+func judge0IntQuery(c *gin.Context, key string, defaultValue int) int {
+	value, err := strconv.Atoi(c.Query(key))
+	if err != nil || value < 1 {
+		return defaultValue
+	}
+	return value
+}
+
+// parseMemoryLimitKB convierte un límite como "256m" a KB (formato de Judge0)
+func parseMemoryLimitKB(limit string) int {
+	if limit == "" {
+		return 0
+	}
+
+	var value int
+	fmt.Sscanf(limit, "%d", &value)
+	switch strings.ToLower(limit[len(limit)-1:]) {
+	case "g":
+		return value * 1024 * 1024
+	case "m":
+		return value * 1024
+	case "k":
+		return value
+	}
+	return value / 1024
+}
+
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder captura la respuesta de un handler nativo para traducirla
+type responseRecorder struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int)              { r.status = code }
+func (r *responseRecorder) WriteHeaderNow()                   {}
+func (r *responseRecorder) Write(data []byte) (int, error)    { return r.body.Write(data) }
+func (r *responseRecorder) WriteString(s string) (int, error) { return r.body.WriteString(s) }
+func (r *responseRecorder) Status() int                       { return r.status }
+func (r *responseRecorder) Size() int                         { return r.body.Len() }
+func (r *responseRecorder) Written() bool                     { return r.body.Len() > 0 }
+
+// callNative ejecuta un handler nativo con body como cuerpo JSON y retorna su respuesta
+func callNative(c *gin.Context, handler gin.HandlerFunc, body interface{}) (int, []byte) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return http.StatusInternalServerError, []byte(`{"error":"Failed to encode request"}`)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	c.Request.ContentLength = int64(len(data))
+
+	original := c.Writer
+	recorder := &responseRecorder{ResponseWriter: original, status: http.StatusOK}
+	c.Writer = recorder
+	handler(c)
+	c.Writer = original
+
+	return recorder.status, recorder.body.Bytes()
+}
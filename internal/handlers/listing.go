@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultListLimit es el tamaño de página si no se indica limit
+	DefaultListLimit = 50
+	// MaxListLimit es el tamaño de página máximo
+	MaxListLimit = 500
+)
+
+// listSubmissions maneja GET /submissions con filtros, orden, cursor y fields=
+//
+//	?status=completed&language_id=71&problem_id=p1&user_id=u1
+//	&created_after=2026-01-01T00:00:00Z&created_before=...
+//	&order=desc&limit=50&cursor=...&fields=id,status,time
+func listSubmissions(c *gin.Context, db database.Store) {
+	query, fields, err := parseSubmissionQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	submissions, next, err := db.QuerySubmissions(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+		return
+	}
+
+	var nextCursor *string
+	if next != nil {
+		token := next.Encode()
+		nextCursor = &token
+	}
+
+	presented := presentSubmissions(c, submissions)
+	var items interface{} = presented
+	if len(fields) > 0 {
+		items, err = projectSubmissions(presented, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get submissions"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"submissions": items,
+		"next_cursor": nextCursor,
+	})
+}
+
+// parseSubmissionQuery lee los query params del listado
+func parseSubmissionQuery(c *gin.Context) (database.SubmissionQuery, []string, error) {
+	query := database.SubmissionQuery{
+		Status:    c.Query("status"),
+		ProblemID: c.Query("problem_id"),
+		UserID:    c.Query("user_id"),
+		Limit:     DefaultListLimit,
+	}
+
+	// Solo las del tenant y, con read-own, solo las propias
+	scope := readFilter(c)
+	query.TenantID = scope.TenantID
+	query.APIKeyID = scope.APIKeyID
+	query.Own = scope.Own
+	if scope.UserID != "" {
+		query.UserID = scope.UserID
+	}
+
+	if value := c.Query("language_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return query, nil, fmt.Errorf("language_id must be a number")
+		}
+		query.LanguageID = id
+	}
+
+	for param, target := range map[string]**time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+	} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, nil, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+			// created_at se guarda en UTC: comparar con el mismo instante en UTC
+			t = t.UTC()
+			*target = &t
+		}
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		query.Ascending = true
+	case "desc":
+	default:
+		return query, nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return query, nil, fmt.Errorf("limit must be a positive number")
+		}
+		if limit > MaxListLimit {
+			limit = MaxListLimit
+		}
+		query.Limit = limit
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := database.DecodeSubmissionCursor(value)
+		if err != nil {
+			return query, nil, err
+		}
+		query.After = cursor
+	}
+
+	var fields []string
+	if value := c.Query("fields"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !database.IsSubmissionField(field) {
+				return query, nil, fmt.Errorf("invalid field: %s", field)
+			}
+			fields = append(fields, field)
+		}
+		query.Fields = fields
+	}
+
+	return query, fields, nil
+}
+
+// projectSubmissions retorna solo los campos pedidos de cada submission
+func projectSubmissions(submissions []models.Submission, fields []string) ([]map[string]interface{}, error) {
+	projected := make([]map[string]interface{}, 0, len(submissions))
+	for i := range submissions {
+		data, err := json.Marshal(&submissions[i])
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]interface{}
+		if err := json.Unmarshal(data, &full); err != nil {
+			return nil, err
+		}
+
+		item := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			item[field] = full[field] // los campos vacíos con omitempty quedan en null
+		}
+		projected = append(projected, item)
+	}
+	return projected, nil
+}
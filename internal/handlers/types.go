@@ -1,11 +1,95 @@
 package handlers
 
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
 // CreateSubmissionRequest representa una petición para crear una submission
 type CreateSubmissionRequest struct {
-	LanguageID     int    `json:"language_id" binding:"required"`
-	SourceCode     string `json:"source_code" binding:"required"`
-	Stdin          string `json:"stdin"`
-	ExpectedOutput string `json:"expected_output"`
-	Priority       int    `json:"priority"`
-	WebhookURL     string `json:"webhook_url,omitempty"`
+	LanguageID     int        `json:"language_id" binding:"required"`
+	SourceCode     string     `json:"source_code" binding:"required"`
+	Stdin          string     `json:"stdin"`
+	ExpectedOutput string     `json:"expected_output"`
+	Priority       int        `json:"priority"`
+	WebhookURL     string     `json:"webhook_url,omitempty"`
+	RunAt          *time.Time `json:"run_at,omitempty"`        // ejecutar en una fecha específica
+	DelaySeconds   int        `json:"delay_seconds,omitempty"` // ejecutar después de N segundos
+	ProblemID      string     `json:"problem_id,omitempty"`
+	UserID         string     `json:"user_id,omitempty"`
+}
+
+// CreateBatchRequest representa una petición para crear varias submissions
+type CreateBatchRequest struct {
+	Submissions []CreateSubmissionRequest `json:"submissions" binding:"required,dive"`
+}
+
+// IsScheduled indica si la petición pide ejecución diferida
+func (r *CreateSubmissionRequest) IsScheduled() bool {
+	return r.RunAt != nil || r.DelaySeconds != 0
+}
+
+// ScheduledTime calcula cuándo debe entrar a la cola la submission.
+// Retorna nil si debe encolarse inmediatamente.
+func (r *CreateSubmissionRequest) ScheduledTime(now time.Time) (*time.Time, error) {
+	if r.RunAt != nil && r.DelaySeconds != 0 {
+		return nil, fmt.Errorf("run_at and delay_seconds are mutually exclusive")
+	}
+	if r.DelaySeconds < 0 {
+		return nil, fmt.Errorf("delay_seconds must be positive")
+	}
+
+	// scheduled_at se guarda en UTC, sin la zona de quien llama
+	var runAt time.Time
+	if r.RunAt != nil {
+		runAt = r.RunAt.UTC()
+	} else if r.DelaySeconds > 0 {
+		runAt = now.UTC().Add(time.Duration(r.DelaySeconds) * time.Second)
+	}
+
+	// Fechas en el pasado se ejecutan inmediatamente
+	if runAt.IsZero() || !runAt.After(now) {
+		return nil, nil
+	}
+	return &runAt, nil
+}
+
+const (
+	// DefaultWaitTimeout es el tiempo que espera wait=true si no se indica wait_timeout
+	DefaultWaitTimeout = 30 * time.Second
+	// MaxWaitTimeout es el máximo permitido para wait_timeout
+	MaxWaitTimeout = 120 * time.Second
+)
+
+// parseWaitTimeout lee el query param wait_timeout (segundos)
+func parseWaitTimeout(c *gin.Context) (time.Duration, error) {
+	value := c.Query("wait_timeout")
+	if value == "" {
+		return DefaultWaitTimeout, nil
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("wait_timeout must be a positive number of seconds")
+	}
+
+	timeout := time.Duration(seconds) * time.Second
+	if timeout > MaxWaitTimeout {
+		timeout = MaxWaitTimeout
+	}
+	return timeout, nil
+}
+
+// clampPriority limita la prioridad al rango permitido [-10, 10]
+func clampPriority(priority int) int {
+	if priority > 10 {
+		return 10
+	}
+	if priority < -10 {
+		return -10
+	}
+	return priority
 }
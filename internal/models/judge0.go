@@ -0,0 +1,97 @@
+package models
+
+import "strings"
+
+// IDs de estado de Judge0 (usados por la API compatible)
+const (
+	Judge0InQueue           = 1
+	Judge0Processing        = 2
+	Judge0Accepted          = 3
+	Judge0WrongAnswer       = 4
+	Judge0TimeLimitExceeded = 5
+	Judge0CompilationError  = 6
+	Judge0RuntimeSIGSEGV    = 7
+	Judge0RuntimeSIGXFSZ    = 8
+	Judge0RuntimeSIGFPE     = 9
+	Judge0RuntimeSIGABRT    = 10
+	Judge0RuntimeNZEC       = 11
+	Judge0RuntimeOther      = 12
+	Judge0InternalError     = 13
+	Judge0ExecFormatError   = 14
+)
+
+// Judge0Status es un estado con el formato de Judge0: {id, description}
+type Judge0Status struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+}
+
+// Judge0Statuses es la lista de estados que retorna GET /statuses
+var Judge0Statuses = []Judge0Status{
+	{Judge0InQueue, "In Queue"},
+	{Judge0Processing, "Processing"},
+	{Judge0Accepted, "Accepted"},
+	{Judge0WrongAnswer, "Wrong Answer"},
+	{Judge0TimeLimitExceeded, "Time Limit Exceeded"},
+	{Judge0CompilationError, "Compilation Error"},
+	{Judge0RuntimeSIGSEGV, "Runtime Error (SIGSEGV)"},
+	{Judge0RuntimeSIGXFSZ, "Runtime Error (SIGXFSZ)"},
+	{Judge0RuntimeSIGFPE, "Runtime Error (SIGFPE)"},
+	{Judge0RuntimeSIGABRT, "Runtime Error (SIGABRT)"},
+	{Judge0RuntimeNZEC, "Runtime Error (NZEC)"},
+	{Judge0RuntimeOther, "Runtime Error (Other)"},
+	{Judge0InternalError, "Internal Error"},
+	{Judge0ExecFormatError, "Exec Format Error"},
+}
+
+// Judge0Status traduce el estado de la submission al estado equivalente de Judge0
+func (s *Submission) Judge0Status() Judge0Status {
+	id := s.judge0StatusID()
+	return Judge0Statuses[id-1]
+}
+
+func (s *Submission) judge0StatusID() int {
+	switch s.Status {
+	case StatusScheduled, StatusQueued:
+		return Judge0InQueue
+	case StatusProcessing:
+		return Judge0Processing
+	case StatusTimeout:
+		return Judge0TimeLimitExceeded
+	case StatusCompleted:
+		// se evalúa abajo
+	default:
+		// error, cancelled
+		return Judge0InternalError
+	}
+
+	if s.ExitCode != 0 {
+		if s.CompileOut != "" {
+			return Judge0CompilationError
+		}
+		// 128 + número de señal
+		switch s.ExitCode {
+		case 139:
+			return Judge0RuntimeSIGSEGV
+		case 153:
+			return Judge0RuntimeSIGXFSZ
+		case 136:
+			return Judge0RuntimeSIGFPE
+		case 134:
+			return Judge0RuntimeSIGABRT
+		case 126:
+			return Judge0ExecFormatError
+		}
+		if s.ExitCode > 128 {
+			return Judge0RuntimeOther
+		}
+		return Judge0RuntimeNZEC
+	}
+
+	// Igual que Judge0, se ignoran los espacios al final
+	if s.ExpectedOut != "" &&
+		strings.TrimRight(s.Stdout, " \t\r\n") != strings.TrimRight(s.ExpectedOut, " \t\r\n") {
+		return Judge0WrongAnswer
+	}
+	return Judge0Accepted
+}
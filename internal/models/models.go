@@ -2,6 +2,7 @@ package models
 
 import (
 	"time"
+	"unicode/utf8"
 )
 
 // Submission representa una solicitud de ejecución de código
@@ -11,7 +12,7 @@ type Submission struct {
 	SourceCode  string     `json:"source_code" db:"source_code"`
 	Stdin       string     `json:"stdin,omitempty" db:"stdin"`
 	ExpectedOut string     `json:"expected_output,omitempty" db:"expected_output"`
-	Status      string     `json:"status" db:"status"` // queued, processing, completed, error
+	Status      string     `json:"status" db:"status"` // scheduled, queued, processing, completed, error, cancelled
 	Stdout      string     `json:"stdout,omitempty" db:"stdout"`
 	Stderr      string     `json:"stderr,omitempty" db:"stderr"`
 	ExitCode    int        `json:"exit_code" db:"exit_code"`
@@ -21,7 +22,26 @@ type Submission struct {
 	Message     string     `json:"message,omitempty" db:"message"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	FinishedAt  *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" db:"scheduled_at"` // cuándo debe entrar a la cola
 	WebhookURL  string     `json:"webhook_url,omitempty"`
+	ProblemID   string     `json:"problem_id,omitempty" db:"problem_id"` // problema/ejercicio al que responde
+	UserID      string     `json:"user_id,omitempty" db:"user_id"`       // usuario final que la envió
+	APIKeyID    string     `json:"api_key_id,omitempty" db:"api_key_id"` // API key que la creó (dueño)
+	TenantID    string     `json:"tenant_id,omitempty" db:"tenant_id"`   // tenant al que pertenece
+
+	// Datos del job, para reencolarla igual que al crearla (modo all-in-one)
+	Priority int    `json:"-" db:"priority"`
+	QuotaKey string `json:"-" db:"quota_key"` // a quién se carga la CPU consumida
+
+	// Cuándo la retención borró el código, la entrada y las salidas (se conservan los metadatos)
+	PayloadPurgedAt *time.Time `json:"payload_purged_at,omitempty" db:"payload_purged_at"`
+
+	// Salidas guardadas fuera de línea, con links de descarga (BLOB_DELIVERY=link)
+	Blobs map[string]BlobRef `json:"blobs,omitempty"`
+
+	// Información de cola (no se persiste, solo para submissions en espera)
+	QueuePosition    *int64     `json:"queue_position,omitempty"`
+	EstimatedStartAt *time.Time `json:"estimated_start_at,omitempty"`
 }
 
 // SubmissionRequest es lo que recibe la API
@@ -41,6 +61,151 @@ type SubmissionResponse struct {
 	Token  string `json:"token,omitempty"` // para consultar el resultado después
 }
 
+// IdempotencyKey asocia un Idempotency-Key con la submission que creó
+type IdempotencyKey struct {
+	Key          string    `json:"key" db:"key"`
+	Fingerprint  string    `json:"fingerprint" db:"fingerprint"` // hash del cuerpo de la petición
+	SubmissionID string    `json:"submission_id" db:"submission_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// APIKey es una credencial de acceso a la API. Solo se guarda el hash de la key.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"key_prefix"` // primeros caracteres, para identificarla
+	Hash       string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	Plan       string     `json:"plan,omitempty" db:"plan"`           // plan de rate limiting y cuotas ("" = default)
+	TenantID   string     `json:"tenant_id,omitempty" db:"tenant_id"` // "" = key global
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Tenant es una organización (p.ej. una escuela) con sus datos y configuración aislados
+type Tenant struct {
+	ID               string    `json:"id" db:"id"`
+	Name             string    `json:"name" db:"name"`
+	EnabledLanguages []int     `json:"enabled_languages" db:"enabled_languages"` // vacío = todos los habilitados
+	MaxTimeLimit     float64   `json:"max_time_limit" db:"max_time_limit"`       // segundos; 0 = límite global
+	MaxMemoryMB      int       `json:"max_memory_mb" db:"max_memory_mb"`         // 0 = límite global
+	MaxCPUs          float64   `json:"max_cpus" db:"max_cpus"`                   // 0 = límite global
+	WebhookSecret    string    `json:"-" db:"webhook_secret"`                    // "" = WEBHOOK_SECRET global
+	Plan             string    `json:"plan" db:"plan"`                           // plan de cuotas del tenant ("" = default)
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// LanguageEnabled indica si el tenant puede usar el lenguaje
+func (t *Tenant) LanguageEnabled(languageID int) bool {
+	if len(t.EnabledLanguages) == 0 {
+		return true
+	}
+	for _, id := range t.EnabledLanguages {
+		if id == languageID {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageRecord es el consumo medido de una submission (ledger de facturación)
+type UsageRecord struct {
+	SubmissionID    string    `json:"submission_id" db:"submission_id"`
+	TenantID        string    `json:"tenant_id" db:"tenant_id"`
+	APIKeyID        string    `json:"api_key_id" db:"api_key_id"`
+	UserID          string    `json:"user_id" db:"user_id"`
+	LanguageID      int       `json:"language_id" db:"language_id"`
+	LanguageName    string    `json:"language_name" db:"language_name"`
+	CPUSeconds      float64   `json:"cpu_seconds" db:"cpu_seconds"`
+	WallSeconds     float64   `json:"wall_seconds" db:"wall_seconds"`
+	MemoryMBSeconds float64   `json:"memory_mb_seconds" db:"memory_mb_seconds"` // pico de memoria (MB) × tiempo de reloj
+	CompileSeconds  float64   `json:"compile_seconds" db:"compile_seconds"`
+	RecordedAt      time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// NewUsageRecord mide el consumo de una ejecución terminada. Si Docker no reportó
+// el tiempo de CPU se usa el tiempo de reloj.
+func NewUsageRecord(sub *Submission, language *Language, result ExecutionResult, now time.Time) *UsageRecord {
+	cpu := result.CPUTime
+	if cpu <= 0 {
+		cpu = result.Time
+	}
+	peak := result.PeakMemory
+	if peak < result.Memory {
+		peak = result.Memory
+	}
+
+	return &UsageRecord{
+		SubmissionID:    sub.ID,
+		TenantID:        sub.TenantID,
+		APIKeyID:        sub.APIKeyID,
+		UserID:          sub.UserID,
+		LanguageID:      language.ID,
+		LanguageName:    language.Name,
+		CPUSeconds:      cpu,
+		WallSeconds:     result.Time,
+		MemoryMBSeconds: float64(peak) / 1024 * result.Time,
+		CompileSeconds:  result.CompileTime,
+		RecordedAt:      now.UTC(),
+	}
+}
+
+// UsageSummary es el consumo agregado por día, tenant y API key
+type UsageSummary struct {
+	Day             string  `json:"day"` // YYYY-MM-DD (UTC)
+	TenantID        string  `json:"tenant_id"`
+	APIKeyID        string  `json:"api_key_id"`
+	Submissions     int64   `json:"submissions"`
+	CPUSeconds      float64 `json:"cpu_seconds"`
+	WallSeconds     float64 `json:"wall_seconds"`
+	MemoryMBSeconds float64 `json:"memory_mb_seconds"`
+	CompileSeconds  float64 `json:"compile_seconds"`
+}
+
+// BlobRef es un campo de una submission guardado en el blob store
+type BlobRef struct {
+	Hash      string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WebhookLog es un intento de entrega de webhook
+type WebhookLog struct {
+	ID           int64     `json:"id" db:"id"`
+	SubmissionID string    `json:"submission_id" db:"submission_id"`
+	WebhookURL   string    `json:"webhook_url" db:"webhook_url"`
+	Attempt      int       `json:"attempt" db:"attempt"`
+	StatusCode   int       `json:"status_code" db:"status_code"`
+	ResponseBody string    `json:"response_body,omitempty" db:"response_body"`
+	Error        string    `json:"error,omitempty" db:"error"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// RetentionRun es una ejecución del janitor de retención
+type RetentionRun struct {
+	ID                 int64      `json:"id" db:"id"`
+	TriggeredBy        string     `json:"triggered_by" db:"triggered_by"` // schedule o manual
+	Status             string     `json:"status" db:"status"`             // running, completed o failed
+	PayloadsPurged     int64      `json:"payloads_purged" db:"payloads_purged"`
+	SubmissionsDeleted int64      `json:"submissions_deleted" db:"submissions_deleted"`
+	WebhookLogsDeleted int64      `json:"webhook_logs_deleted" db:"webhook_logs_deleted"`
+	Archived           int64      `json:"archived" db:"archived"` // registros escritos al archivo
+	ArchiveFile        string     `json:"archive_file,omitempty" db:"archive_file"`
+	Error              string     `json:"error,omitempty" db:"error"`
+	StartedAt          time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt         *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// Estados de una ejecución de retención
+const (
+	RetentionRunning   = "running"
+	RetentionCompleted = "completed"
+	RetentionFailed    = "failed"
+)
+
 // Language representa un lenguaje de programación soportado
 type Language struct {
 	ID          int    `json:"id" db:"id"`
@@ -53,17 +218,69 @@ type Language struct {
 	DockerImage string `json:"docker_image" db:"docker_image"`
 	IsCompiled  bool   `json:"is_compiled" db:"is_compiled"` // true para C, C++, Go, etc.
 	IsEnabled   bool   `json:"is_enabled" db:"is_enabled"`
+
+	// Programa de prueba que debe pasar antes de habilitar el lenguaje
+	SmokeTestCode   string `json:"smoke_test_code,omitempty" db:"smoke_test_code"`
+	SmokeTestOutput string `json:"smoke_test_output,omitempty" db:"smoke_test_output"` // salida esperada
+
+	Revision  int        `json:"revision" db:"revision"`     // aumenta con cada cambio
+	ManagedBy string     `json:"managed_by" db:"managed_by"` // seed o admin
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// Quién mantiene un lenguaje: el seed lo actualiza al arrancar hasta que un admin lo cambia
+const (
+	LanguageManagedBySeed  = "seed"
+	LanguageManagedByAdmin = "admin"
+)
+
+// SmokeTestResult es el resultado de correr el programa de prueba de un lenguaje
+type SmokeTestResult struct {
+	Passed        bool    `json:"passed"`
+	Stdout        string  `json:"stdout"`
+	Stderr        string  `json:"stderr,omitempty"`
+	CompileOutput string  `json:"compile_output,omitempty"`
+	ExitCode      int     `json:"exit_code"`
+	Time          float64 `json:"time"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// LanguageRevision es una versión guardada de un lenguaje
+type LanguageRevision struct {
+	ID         int64            `json:"id" db:"id"`
+	LanguageID int              `json:"language_id" db:"language_id"`
+	Revision   int              `json:"revision" db:"revision"`
+	Action     string           `json:"action" db:"action"` // seed, create, update, enable, disable o restore
+	Language   Language         `json:"language" db:"snapshot"`
+	ChangedBy  string           `json:"changed_by,omitempty" db:"changed_by"`
+	SmokeTest  *SmokeTestResult `json:"smoke_test,omitempty" db:"smoke_test"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
 }
 
+// Acciones del historial de lenguajes
+const (
+	LanguageActionSeed    = "seed"
+	LanguageActionCreate  = "create"
+	LanguageActionUpdate  = "update"
+	LanguageActionEnable  = "enable"
+	LanguageActionDisable = "disable"
+	LanguageActionRestore = "restore"
+)
+
 // Status constants
 const (
+	StatusScheduled  = "scheduled"
 	StatusQueued     = "queued"
 	StatusProcessing = "processing"
 	StatusCompleted  = "completed"
 	StatusError      = "error"
 	StatusTimeout    = "timeout"
+	StatusCancelled  = "cancelled"
 )
 
+// FinishedStatuses son los estados finales de una submission
+var FinishedStatuses = []string{StatusCompleted, StatusError, StatusTimeout, StatusCancelled}
+
 // Language IDs (como Judge0)
 const (
 	LanguagePython3    = 71
@@ -77,14 +294,17 @@ const (
 
 // ExecutionResult contiene los resultados de la ejecución
 type ExecutionResult struct {
-	Stdout     string
-	Stderr     string
-	ExitCode   int
-	Time       float64 // en segundos
-	Memory     int     // en KB
-	CompileOut string
-	Error      string
-	TimedOut   bool
+	Stdout      string
+	Stderr      string
+	ExitCode    int
+	Time        float64 // en segundos (reloj)
+	Memory      int     // en KB
+	CompileOut  string
+	Error       string
+	TimedOut    bool
+	CPUTime     float64 // segundos de CPU consumidos (0 si Docker no los reporta)
+	PeakMemory  int     // pico de memoria en KB
+	CompileTime float64 // segundos de compilación (lenguajes compilados)
 }
 
 // NewSubmission crea una nueva submission con valores por defecto
@@ -97,7 +317,7 @@ func NewSubmission(req SubmissionRequest, id string) *Submission {
 		ExpectedOut: req.ExpectedOut,
 		WebhookURL:  req.WebhookURL,
 		Status:      StatusQueued,
-		CreatedAt:   time.Now(),
+		CreatedAt:   time.Now().UTC(),
 		ExitCode:    -1,
 	}
 }
@@ -106,7 +326,19 @@ func NewSubmission(req SubmissionRequest, id string) *Submission {
 func (s *Submission) IsFinished() bool {
 	return s.Status == StatusCompleted ||
 		s.Status == StatusError ||
-		s.Status == StatusTimeout
+		s.Status == StatusTimeout ||
+		s.Status == StatusCancelled
+}
+
+// IsValidUTF8 indica si todos los textos de la submission son UTF-8 válido
+// (la salida de un programa puede contener bytes arbitrarios)
+func (s *Submission) IsValidUTF8() bool {
+	for _, value := range []string{s.SourceCode, s.Stdin, s.ExpectedOut, s.Stdout, s.Stderr, s.CompileOut, s.Message} {
+		if !utf8.ValidString(value) {
+			return false
+		}
+	}
+	return true
 }
 
 // MarkAsProcessing marca la submission como en procesamiento
@@ -116,7 +348,7 @@ func (s *Submission) MarkAsProcessing() {
 
 // MarkAsCompleted marca la submission como completada
 func (s *Submission) MarkAsCompleted(result ExecutionResult) {
-	now := time.Now()
+	now := time.Now().UTC()
 	s.Status = StatusCompleted
 	s.Stdout = result.Stdout
 	s.Stderr = result.Stderr
@@ -132,9 +364,17 @@ func (s *Submission) MarkAsCompleted(result ExecutionResult) {
 	}
 }
 
+// MarkAsCancelled marca la submission como cancelada antes de ejecutarse
+func (s *Submission) MarkAsCancelled() {
+	now := time.Now().UTC()
+	s.Status = StatusCancelled
+	s.Message = "Cancelled before execution"
+	s.FinishedAt = &now
+}
+
 // MarkAsError marca la submission como error
 func (s *Submission) MarkAsError(errMsg string) {
-	now := time.Now()
+	now := time.Now().UTC()
 	s.Status = StatusError
 	s.Message = errMsg
 	s.FinishedAt = &now
@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// eventsChannelPrefix + submission ID es el canal pub/sub de cada submission
+const eventsChannelPrefix = "rojudger:events:"
+
+// outputKeyPrefix + submission ID guarda los fragmentos de salida ya publicados,
+// para que un cliente que se conecta tarde vea la salida desde el inicio
+const outputKeyPrefix = "rojudger:output:"
+
+// OutputRetention es cuánto se conservan los fragmentos de salida en Redis
+const OutputRetention = time.Hour
+
+// Tipos de evento
+const (
+	EventStatus = "status" // cambio de estado (queued, processing)
+	EventResult = "result" // la submission terminó (completed, error, timeout, cancelled)
+	EventOutput = "output" // fragmento de stdout/stderr mientras corre
+)
+
+// EncodingBase64 indica que el Data de un evento output viene codificado en base64
+const EncodingBase64 = "base64"
+
+// Event es un evento publicado sobre una submission
+type Event struct {
+	Type         string    `json:"type"`
+	SubmissionID string    `json:"submission_id"`
+	Status       string    `json:"status,omitempty"`
+	Stream       string    `json:"stream,omitempty"`   // stdout o stderr (solo output)
+	Data         string    `json:"data,omitempty"`     // fragmento de salida (solo output)
+	Encoding     string    `json:"encoding,omitempty"` // "base64" si el fragmento no es UTF-8 válido
+	Seq          int64     `json:"seq,omitempty"`      // orden del fragmento, empieza en 1
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Publish publica un evento en el canal de la submission
+func (q *RedisQueue) Publish(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := q.client.Publish(ctx, eventsChannelPrefix+event.SubmissionID, data).Err(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// outputEvent arma el evento de un fragmento de salida. Los bytes que no son UTF-8
+// válido (salida binaria o un carácter partido entre fragmentos) se envían en
+// base64 para que JSON no los reemplace por U+FFFD.
+func outputEvent(submissionID string, seq int64, stream string, data []byte) Event {
+	event := Event{
+		Type:         EventOutput,
+		SubmissionID: submissionID,
+		Stream:       stream,
+		Data:         string(data),
+		Seq:          seq,
+		Timestamp:    time.Now(),
+	}
+	if !utf8.Valid(data) {
+		event.Data = base64.StdEncoding.EncodeToString(data)
+		event.Encoding = EncodingBase64
+	}
+	return event
+}
+
+// PublishOutput guarda un fragmento de salida en el historial de la submission y lo publica
+func (q *RedisQueue) PublishOutput(ctx context.Context, submissionID string, seq int64, stream string, data []byte) error {
+	event := outputEvent(submissionID, seq, stream, data)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	key := outputKeyPrefix + submissionID
+	pipe := q.client.Pipeline()
+	pipe.RPush(ctx, key, payload)
+	pipe.Expire(ctx, key, OutputRetention)
+	pipe.Publish(ctx, eventsChannelPrefix+submissionID, payload)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to publish output: %w", err)
+	}
+	return nil
+}
+
+// OutputHistory retorna los fragmentos de salida publicados hasta ahora, en orden
+func (q *RedisQueue) OutputHistory(ctx context.Context, submissionID string) ([]Event, error) {
+	items, err := q.client.LRange(ctx, outputKeyPrefix+submissionID, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get output history: %w", err)
+	}
+
+	events := make([]Event, 0, len(items))
+	for _, item := range items {
+		var event Event
+		if err := json.Unmarshal([]byte(item), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ClearOutput borra el historial de salida (p.ej. al reencolar una submission)
+func (q *RedisQueue) ClearOutput(ctx context.Context, submissionID string) error {
+	return q.client.Del(ctx, outputKeyPrefix+submissionID).Err()
+}
+
+// Subscription recibe los eventos de una submission
+type Subscription struct {
+	events  chan Event
+	done    chan struct{}
+	once    sync.Once
+	release func() error // libera los recursos del backend
+}
+
+// newSubscription crea una suscripción; release se llama al cerrarla
+func newSubscription(release func() error) *Subscription {
+	return &Subscription{
+		events:  make(chan Event, 16),
+		done:    make(chan struct{}),
+		release: release,
+	}
+}
+
+// Subscribe se suscribe a los eventos de una submission. La suscripción queda
+// activa al retornar, por lo que no se pierden eventos publicados después.
+func (q *RedisQueue) Subscribe(ctx context.Context, submissionID string) (*Subscription, error) {
+	pubsub := q.client.Subscribe(ctx, eventsChannelPrefix+submissionID)
+
+	// Esperar la confirmación de la suscripción
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	sub := newSubscription(pubsub.Close)
+
+	go func() {
+		defer close(sub.events)
+		for msg := range pubsub.Channel() {
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("Invalid event on %s: %v", msg.Channel, err)
+				continue
+			}
+			select {
+			case sub.events <- event:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+// Events retorna el canal de eventos (se cierra al cerrar la suscripción)
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close cancela la suscripción
+func (s *Subscription) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return s.release()
+}
@@ -0,0 +1,673 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/constants"
+)
+
+// publishTimeout es cuánto se espera a un suscriptor lento antes de descartar el evento
+const publishTimeout = time.Second
+
+// MemoryQueue implementa Queue dentro del proceso, sin Redis (modo all-in-one).
+// Respeta las mismas prioridades y colas por lenguaje que RedisQueue, pero su
+// contenido se pierde al reiniciar: al arrancar hay que reencolar las
+// submissions pendientes de la base de datos.
+type MemoryQueue struct {
+	mu          sync.Mutex
+	queues      map[string][]Job // por clave de cola; el primer trabajo es el próximo
+	routed      map[int]struct{} // lenguajes que tuvieron colas propias
+	processing  map[string]struct{}
+	counters    map[string]int64
+	completions []time.Time
+	scheduled   map[string]scheduledJob
+	output      map[string]*memoryOutput
+	subscribers map[string]map[*Subscription]chan Event
+	workers     map[string]memoryWorker
+	drains      map[string]struct{}
+	wakeup      chan struct{} // se cierra (y reemplaza) cada vez que entra un trabajo
+}
+
+// scheduledJob es un trabajo programado que espera su run_at
+type scheduledJob struct {
+	job   Job
+	runAt time.Time
+}
+
+// memoryOutput guarda los fragmentos de salida publicados de una submission
+type memoryOutput struct {
+	events    []Event
+	expiresAt time.Time
+}
+
+// memoryWorker es un worker registrado y la expiración de su heartbeat
+type memoryWorker struct {
+	info      WorkerInfo
+	expiresAt time.Time
+}
+
+// NewMemoryQueue crea una cola vacía en memoria
+func NewMemoryQueue() *MemoryQueue {
+	log.Println("In-memory queue ready (all-in-one mode)")
+
+	return &MemoryQueue{
+		queues:      make(map[string][]Job),
+		routed:      make(map[int]struct{}),
+		processing:  make(map[string]struct{}),
+		counters:    make(map[string]int64),
+		scheduled:   make(map[string]scheduledJob),
+		output:      make(map[string]*memoryOutput),
+		subscribers: make(map[string]map[*Subscription]chan Event),
+		workers:     make(map[string]memoryWorker),
+		drains:      make(map[string]struct{}),
+		wakeup:      make(chan struct{}),
+	}
+}
+
+// Enqueue añade un trabajo a la cola de su lenguaje o al pool general
+func (q *MemoryQueue) Enqueue(ctx context.Context, job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.CreatedAt = time.Now()
+	job.Queue = q.pushLocked(job)
+	q.counters["total_enqueued"]++
+	q.notifyLocked()
+
+	log.Printf("Job enqueued: %s (priority: %d, queue: %s)", job.SubmissionID, job.Priority, job.Queue)
+	return nil
+}
+
+// EnqueueBatch añade varios trabajos de una vez
+func (q *MemoryQueue) EnqueueBatch(ctx context.Context, jobs []Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for _, job := range jobs {
+		job.CreatedAt = now
+		q.pushLocked(job)
+	}
+	q.counters["total_enqueued"] += int64(len(jobs))
+	q.notifyLocked()
+
+	log.Printf("Batch enqueued: %d jobs", len(jobs))
+	return nil
+}
+
+// Dequeue toma el próximo trabajo de las colas indicadas, esperando hasta timeout.
+// Retorna nil si no llegó ningún trabajo.
+func (q *MemoryQueue) Dequeue(ctx context.Context, timeout time.Duration, languages []int, generalPool bool) (*Job, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		q.mu.Lock()
+		for _, key := range dequeueKeys(languages, generalPool, q.routedLocked()) {
+			jobs := q.queues[key]
+			if len(jobs) == 0 {
+				continue
+			}
+
+			job := jobs[0]
+			if len(jobs) == 1 {
+				delete(q.queues, key)
+			} else {
+				q.queues[key] = jobs[1:]
+			}
+			q.processing[job.SubmissionID] = struct{}{}
+			q.counters["total_dequeued"]++
+			q.mu.Unlock()
+
+			log.Printf("Job dequeued: %s from %s", job.SubmissionID, key)
+			return &job, nil
+		}
+		wakeup := q.wakeup
+		q.mu.Unlock()
+
+		select {
+		case <-wakeup:
+		case <-deadline.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to dequeue job: %w", ctx.Err())
+		}
+	}
+}
+
+// Requeue devuelve un trabajo interrumpido al frente de su cola
+func (q *MemoryQueue) Requeue(ctx context.Context, job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.Queue = q.routeJobLocked(job)
+	q.queues[job.Queue] = append([]Job{job}, q.queues[job.Queue]...)
+	delete(q.processing, job.SubmissionID)
+	q.counters["total_requeued"]++
+	q.notifyLocked()
+
+	log.Printf("Job requeued: %s (queue: %s)", job.SubmissionID, job.Queue)
+	return nil
+}
+
+// MarkComplete marca un trabajo como completado
+func (q *MemoryQueue) MarkComplete(ctx context.Context, submissionID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.processing, submissionID)
+	q.counters["total_completed"]++
+	q.recordCompletionLocked()
+
+	log.Printf("Job marked complete: %s", submissionID)
+	return nil
+}
+
+// MarkFailed marca un trabajo como fallido y lo reencola (opcionalmente)
+func (q *MemoryQueue) MarkFailed(ctx context.Context, job Job, retry bool) error {
+	q.mu.Lock()
+	delete(q.processing, job.SubmissionID)
+	q.counters["total_failed"]++
+	q.recordCompletionLocked()
+	q.mu.Unlock()
+
+	if retry {
+		return q.Enqueue(ctx, retryJob(job))
+	}
+
+	log.Printf("Job marked failed: %s (retry: %v)", job.SubmissionID, retry)
+	return nil
+}
+
+// pushLocked añade un trabajo al final de su cola y retorna la cola elegida
+func (q *MemoryQueue) pushLocked(job Job) string {
+	job.Queue = q.routeJobLocked(job)
+	q.queues[job.Queue] = append(q.queues[job.Queue], job)
+	return job.Queue
+}
+
+// routeJobLocked decide la cola de un trabajo igual que RedisQueue.routeJob
+func (q *MemoryQueue) routeJobLocked(job Job) string {
+	level := constants.GetQueueName(job.Priority)
+	if job.LanguageID == 0 || !q.languageServedLocked(job.LanguageID) {
+		return queueKey(level, 0)
+	}
+	q.routed[job.LanguageID] = struct{}{}
+	return queueKey(level, job.LanguageID)
+}
+
+// routedLocked retorna los lenguajes que tuvieron colas propias
+func (q *MemoryQueue) routedLocked() []int {
+	languages := make([]int, 0, len(q.routed))
+	for languageID := range q.routed {
+		languages = append(languages, languageID)
+	}
+	sort.Ints(languages)
+	return languages
+}
+
+// languageServedLocked indica si algún worker vivo anuncia el lenguaje
+func (q *MemoryQueue) languageServedLocked(languageID int) bool {
+	now := time.Now()
+	for _, w := range q.workers {
+		if w.expiresAt.Before(now) {
+			continue
+		}
+		for _, id := range w.info.Languages {
+			if id == languageID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notifyLocked despierta a los Dequeue que están esperando
+func (q *MemoryQueue) notifyLocked() {
+	close(q.wakeup)
+	q.wakeup = make(chan struct{})
+}
+
+// levelLengthLocked suma el tamaño de todas las colas de un nivel
+func (q *MemoryQueue) levelLengthLocked(level string) int64 {
+	total := int64(len(q.queues[queueKey(level, 0)]))
+	for languageID := range q.routed {
+		total += int64(len(q.queues[queueKey(level, languageID)]))
+	}
+	return total
+}
+
+// recordCompletionLocked registra un trabajo terminado para el cálculo de throughput
+func (q *MemoryQueue) recordCompletionLocked() {
+	q.completions = append(q.completions, time.Now())
+	q.pruneCompletionsLocked()
+}
+
+// pruneCompletionsLocked descarta los registros fuera de la ventana de throughput
+func (q *MemoryQueue) pruneCompletionsLocked() {
+	cutoff := time.Now().Add(-ThroughputWindow)
+	i := sort.Search(len(q.completions), func(i int) bool {
+		return !q.completions[i].Before(cutoff)
+	})
+	q.completions = q.completions[i:]
+}
+
+// Position retorna la posición (empezando en 1) de una submission entre los
+// trabajos que comparten workers con ella, o 0 si no está esperando
+func (q *MemoryQueue) Position(ctx context.Context, submissionID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, jobs := range q.queues {
+		for i, job := range jobs {
+			if job.SubmissionID != submissionID {
+				continue
+			}
+
+			position := int64(i + 1)
+			jobLevel := constants.GetQueueName(job.Priority)
+			for _, level := range priorityLevels {
+				if level == jobLevel {
+					break
+				}
+				position += int64(len(q.queues[queueKey(level, 0)]))
+				if job.LanguageID != 0 {
+					position += int64(len(q.queues[queueKey(level, job.LanguageID)]))
+				}
+			}
+			return position, nil
+		}
+	}
+	return 0, nil
+}
+
+// EstimateStart estima cuándo empezará un trabajo en la posición indicada
+func (q *MemoryQueue) EstimateStart(ctx context.Context, position int64) *time.Time {
+	throughput, _ := q.Throughput(ctx)
+	return estimateStart(position, throughput)
+}
+
+// GetStats obtiene estadísticas de la cola
+func (q *MemoryQueue) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	highSize := q.levelLengthLocked("high")
+	defaultSize := q.levelLengthLocked("default")
+	lowSize := q.levelLengthLocked("low")
+
+	stats := map[string]interface{}{
+		"queue_high":    highSize,
+		"queue_default": defaultSize,
+		"queue_low":     lowSize,
+		"processing":    int64(len(q.processing)),
+		"total_pending": highSize + defaultSize + lowSize,
+	}
+	for k, v := range q.counters {
+		stats[k] = v
+	}
+	return stats, nil
+}
+
+// GetStatsTyped retorna estadísticas con tipos correctos
+func (q *MemoryQueue) GetStatsTyped(ctx context.Context) (*Stats, error) {
+	throughput, _ := q.Throughput(ctx)
+	byLanguage := q.LanguageQueueLengths(ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := &Stats{
+		QueueHigh:           q.levelLengthLocked("high"),
+		QueueDefault:        q.levelLengthLocked("default"),
+		QueueLow:            q.levelLengthLocked("low"),
+		QueueByLanguage:     byLanguage,
+		Processing:          int64(len(q.processing)),
+		Scheduled:           int64(len(q.scheduled)),
+		TotalEnqueued:       q.counters["total_enqueued"],
+		TotalDequeued:       q.counters["total_dequeued"],
+		TotalCompleted:      q.counters["total_completed"],
+		TotalFailed:         q.counters["total_failed"],
+		TotalScheduled:      q.counters["total_scheduled"],
+		TotalRequeued:       q.counters["total_requeued"],
+		ThroughputPerMinute: throughput * 60,
+	}
+	stats.TotalPending = stats.QueueHigh + stats.QueueDefault + stats.QueueLow
+	return stats, nil
+}
+
+// QueueLength retorna el tamaño total de la cola
+func (q *MemoryQueue) QueueLength(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var total int64
+	for _, level := range priorityLevels {
+		total += q.levelLengthLocked(level)
+	}
+	return total, nil
+}
+
+// LanguageQueueLengths retorna los trabajos pendientes por lenguaje en colas propias
+func (q *MemoryQueue) LanguageQueueLengths(ctx context.Context) map[string]int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lengths := make(map[string]int64)
+	for languageID := range q.routed {
+		var total int64
+		for _, level := range priorityLevels {
+			total += int64(len(q.queues[queueKey(level, languageID)]))
+		}
+		if total > 0 {
+			lengths[strconv.Itoa(languageID)] = total
+		}
+	}
+	return lengths
+}
+
+// Throughput retorna los trabajos terminados por segundo dentro de la ventana reciente
+func (q *MemoryQueue) Throughput(ctx context.Context) (float64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pruneCompletionsLocked()
+	return float64(len(q.completions)) / ThroughputWindow.Seconds(), nil
+}
+
+// Schedule programa un trabajo para que entre a la cola en runAt
+func (q *MemoryQueue) Schedule(ctx context.Context, job Job, runAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.CreatedAt = time.Now()
+	q.scheduled[job.SubmissionID] = scheduledJob{job: job, runAt: runAt}
+	q.counters["total_scheduled"]++
+
+	log.Printf("Job scheduled: %s (priority: %d, run_at: %s)", job.SubmissionID, job.Priority, runAt.Format(time.RFC3339))
+	return nil
+}
+
+// CancelScheduled elimina un trabajo programado antes de que entre a la cola
+func (q *MemoryQueue) CancelScheduled(ctx context.Context, submissionID string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.scheduled[submissionID]; !ok {
+		return false, nil
+	}
+	delete(q.scheduled, submissionID)
+
+	log.Printf("Scheduled job cancelled: %s", submissionID)
+	return true, nil
+}
+
+// PromoteDue mueve los trabajos cuyo run_at ya pasó a las colas de prioridad
+func (q *MemoryQueue) PromoteDue(ctx context.Context, onPromote func(job Job) error) (int, error) {
+	now := time.Now()
+
+	q.mu.Lock()
+	due := make([]scheduledJob, 0)
+	for _, entry := range q.scheduled {
+		if !entry.runAt.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].runAt.Before(due[j].runAt)
+	})
+	if len(due) > promoteBatchSize {
+		due = due[:promoteBatchSize]
+	}
+	for _, entry := range due {
+		delete(q.scheduled, entry.job.SubmissionID)
+	}
+	q.mu.Unlock()
+
+	promoted := 0
+	for i, entry := range due {
+		if onPromote != nil {
+			if err := onPromote(entry.job); err != nil {
+				log.Printf("Failed to promote scheduled job %s: %v", entry.job.SubmissionID, err)
+				q.retryScheduled(entry.job)
+				continue
+			}
+		}
+
+		if err := q.Enqueue(ctx, entry.job); err != nil {
+			// Los que faltaban vuelven a quedar programados tal cual
+			q.retryScheduled(entry.job)
+			q.mu.Lock()
+			for _, rest := range due[i+1:] {
+				q.scheduled[rest.job.SubmissionID] = rest
+			}
+			q.mu.Unlock()
+			return promoted, err
+		}
+		promoted++
+	}
+
+	return promoted, nil
+}
+
+// retryScheduled vuelve a programar un trabajo que no se pudo promover para
+// dentro de promoteRetryDelay
+func (q *MemoryQueue) retryScheduled(job Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.scheduled[job.SubmissionID] = scheduledJob{job: job, runAt: time.Now().Add(promoteRetryDelay)}
+}
+
+// RunScheduler ejecuta PromoteDue periódicamente hasta que se cancele el contexto
+func (q *MemoryQueue) RunScheduler(ctx context.Context, interval time.Duration, onPromote func(job Job) error) {
+	runScheduler(ctx, interval, func() (int, error) {
+		return q.PromoteDue(ctx, onPromote)
+	})
+}
+
+// ScheduledCount retorna la cantidad de trabajos programados
+func (q *MemoryQueue) ScheduledCount(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.scheduled)), nil
+}
+
+// Publish entrega un evento a los suscriptores de la submission
+func (q *MemoryQueue) Publish(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	q.deliver(event)
+	return nil
+}
+
+// PublishOutput guarda un fragmento de salida en el historial de la submission y lo publica
+func (q *MemoryQueue) PublishOutput(ctx context.Context, submissionID string, seq int64, stream string, data []byte) error {
+	event := outputEvent(submissionID, seq, stream, data)
+
+	q.mu.Lock()
+	// Aprovechar el primer fragmento de cada ejecución para descartar historiales vencidos
+	if seq == 1 {
+		for id, output := range q.output {
+			if output.expiresAt.Before(event.Timestamp) {
+				delete(q.output, id)
+			}
+		}
+	}
+	output, ok := q.output[submissionID]
+	if !ok {
+		output = &memoryOutput{}
+		q.output[submissionID] = output
+	}
+	output.events = append(output.events, event)
+	output.expiresAt = event.Timestamp.Add(OutputRetention)
+	q.mu.Unlock()
+
+	q.deliver(event)
+	return nil
+}
+
+// OutputHistory retorna los fragmentos de salida publicados hasta ahora, en orden
+func (q *MemoryQueue) OutputHistory(ctx context.Context, submissionID string) ([]Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	output, ok := q.output[submissionID]
+	if !ok || output.expiresAt.Before(time.Now()) {
+		return []Event{}, nil
+	}
+	return append([]Event(nil), output.events...), nil
+}
+
+// ClearOutput borra el historial de salida (p.ej. al reencolar una submission)
+func (q *MemoryQueue) ClearOutput(ctx context.Context, submissionID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.output, submissionID)
+	return nil
+}
+
+// Subscribe se suscribe a los eventos de una submission
+func (q *MemoryQueue) Subscribe(ctx context.Context, submissionID string) (*Subscription, error) {
+	inbox := make(chan Event, 64)
+
+	var sub *Subscription
+	sub = newSubscription(func() error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.subscribers[submissionID], sub)
+		if len(q.subscribers[submissionID]) == 0 {
+			delete(q.subscribers, submissionID)
+		}
+		return nil
+	})
+
+	q.mu.Lock()
+	if q.subscribers[submissionID] == nil {
+		q.subscribers[submissionID] = make(map[*Subscription]chan Event)
+	}
+	q.subscribers[submissionID][sub] = inbox
+	q.mu.Unlock()
+
+	go func() {
+		defer close(sub.events)
+		for {
+			select {
+			case event := <-inbox:
+				select {
+				case sub.events <- event:
+				case <-sub.done:
+					return
+				}
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+// deliver envía un evento a cada suscriptor; si uno no lo recibe a tiempo se descarta
+func (q *MemoryQueue) deliver(event Event) {
+	q.mu.Lock()
+	targets := make(map[*Subscription]chan Event, len(q.subscribers[event.SubmissionID]))
+	for sub, inbox := range q.subscribers[event.SubmissionID] {
+		targets[sub] = inbox
+	}
+	q.mu.Unlock()
+
+	for sub, inbox := range targets {
+		select {
+		case inbox <- event:
+		case <-sub.done:
+		case <-time.After(publishTimeout):
+			log.Printf("Dropped %s event for %s: subscriber too slow", event.Type, event.SubmissionID)
+		}
+	}
+}
+
+// Heartbeat registra (o refresca) un worker hasta ttl
+func (q *MemoryQueue) Heartbeat(ctx context.Context, info WorkerInfo, ttl time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	info.LastHeartbeat = time.Now()
+	q.workers[info.ID] = memoryWorker{info: info, expiresAt: info.LastHeartbeat.Add(ttl)}
+	return nil
+}
+
+// UnregisterWorker elimina un worker del registro (apagado ordenado)
+func (q *MemoryQueue) UnregisterWorker(ctx context.Context, info WorkerInfo) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.workers, info.ID)
+	delete(q.drains, info.ID)
+	return nil
+}
+
+// RequestDrain pide a un worker vivo que deje de tomar trabajos y se apague.
+// Retorna false si el worker no está registrado.
+func (q *MemoryQueue) RequestDrain(ctx context.Context, workerID string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w, ok := q.workers[workerID]
+	if !ok || w.expiresAt.Before(time.Now()) {
+		return false, nil
+	}
+	q.drains[workerID] = struct{}{}
+	return true, nil
+}
+
+// DrainRequested indica si un admin pidió drenar el worker
+func (q *MemoryQueue) DrainRequested(ctx context.Context, workerID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.drains[workerID]
+	return ok
+}
+
+// ListWorkers retorna los workers vivos y limpia los que ya expiraron
+func (q *MemoryQueue) ListWorkers(ctx context.Context) ([]WorkerInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	workers := make([]WorkerInfo, 0, len(q.workers))
+	for id, w := range q.workers {
+		if w.expiresAt.Before(now) {
+			delete(q.workers, id)
+			continue
+		}
+		workers = append(workers, w.info)
+	}
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].StartedAt.Before(workers[j].StartedAt)
+	})
+
+	return workers, nil
+}
+
+// Health siempre está sana: la cola vive en el mismo proceso
+func (q *MemoryQueue) Health(ctx context.Context) error {
+	return nil
+}
+
+// Close no libera nada: la cola desaparece con el proceso
+func (q *MemoryQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/constants"
+	"github.com/redis/go-redis/v9"
+)
+
+// Position retorna la posición (empezando en 1) de una submission entre todas
+// las colas pendientes que comparten workers con ella, considerando el orden
+// high → default → low.
+// Retorna 0 si la submission no está esperando en ninguna cola.
+func (q *RedisQueue) Position(ctx context.Context, submissionID string) (int64, error) {
+	data, err := q.client.HGet(ctx, JobsKey, submissionID).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	jobQueue := job.Queue
+	if jobQueue == "" {
+		jobQueue = queueKeyForPriority(job.Priority)
+	}
+
+	// LPOS cuenta desde la cabeza; los workers consumen desde la cola (BRPOP)
+	index, err := q.client.LPos(ctx, jobQueue, data, redis.LPosArgs{}).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate job: %w", err)
+	}
+
+	length, err := q.client.LLen(ctx, jobQueue).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue length: %w", err)
+	}
+	position := length - index
+
+	// Sumar los trabajos de niveles que se consumen antes (pool general y
+	// cola del mismo lenguaje, que son las que comparten workers con este job)
+	jobLevel := constants.GetQueueName(job.Priority)
+	for _, level := range priorityLevels {
+		if level == jobLevel {
+			break
+		}
+		ahead, _ := q.client.LLen(ctx, queueKey(level, 0)).Result()
+		position += ahead
+		if job.LanguageID != 0 {
+			ahead, _ = q.client.LLen(ctx, queueKey(level, job.LanguageID)).Result()
+			position += ahead
+		}
+	}
+
+	return position, nil
+}
+
+// EstimateStart estima cuándo empezará a ejecutarse un trabajo en la posición
+// indicada según el throughput reciente. Retorna nil si no hay datos suficientes.
+func (q *RedisQueue) EstimateStart(ctx context.Context, position int64) *time.Time {
+	throughput, err := q.Throughput(ctx)
+	if err != nil {
+		return nil
+	}
+	return estimateStart(position, throughput)
+}
+
+// estimateStart calcula la hora de inicio a partir del throughput (trabajos/segundo)
+func estimateStart(position int64, throughput float64) *time.Time {
+	if throughput == 0 {
+		return nil
+	}
+
+	wait := time.Duration(float64(position) / throughput * float64(time.Second))
+	eta := time.Now().Add(wait)
+	return &eta
+}
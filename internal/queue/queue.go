@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Queue es la cola de trabajos que comparten el API y los workers: prioridades,
+// colas por lenguaje, trabajos programados, eventos en vivo y registro de workers.
+// RedisQueue la implementa para despliegues escalados y MemoryQueue dentro de un
+// solo proceso (modo all-in-one).
+type Queue interface {
+	// Trabajos
+	Enqueue(ctx context.Context, job Job) error
+	EnqueueBatch(ctx context.Context, jobs []Job) error
+	Dequeue(ctx context.Context, timeout time.Duration, languages []int, generalPool bool) (*Job, error)
+	Requeue(ctx context.Context, job Job) error
+	MarkComplete(ctx context.Context, submissionID string) error
+	MarkFailed(ctx context.Context, job Job, retry bool) error
+
+	// Posición y estadísticas
+	Position(ctx context.Context, submissionID string) (int64, error)
+	EstimateStart(ctx context.Context, position int64) *time.Time
+	GetStats(ctx context.Context) (map[string]interface{}, error)
+	GetStatsTyped(ctx context.Context) (*Stats, error)
+	QueueLength(ctx context.Context) (int64, error)
+	LanguageQueueLengths(ctx context.Context) map[string]int64
+	Throughput(ctx context.Context) (float64, error)
+
+	// Trabajos programados
+	Schedule(ctx context.Context, job Job, runAt time.Time) error
+	CancelScheduled(ctx context.Context, submissionID string) (bool, error)
+	PromoteDue(ctx context.Context, onPromote func(job Job) error) (int, error)
+	RunScheduler(ctx context.Context, interval time.Duration, onPromote func(job Job) error)
+	ScheduledCount(ctx context.Context) (int64, error)
+
+	// Eventos en vivo
+	Publish(ctx context.Context, event Event) error
+	PublishOutput(ctx context.Context, submissionID string, seq int64, stream string, data []byte) error
+	OutputHistory(ctx context.Context, submissionID string) ([]Event, error)
+	ClearOutput(ctx context.Context, submissionID string) error
+	Subscribe(ctx context.Context, submissionID string) (*Subscription, error)
+
+	// Registro de workers
+	Heartbeat(ctx context.Context, info WorkerInfo, ttl time.Duration) error
+	UnregisterWorker(ctx context.Context, info WorkerInfo) error
+	RequestDrain(ctx context.Context, workerID string) (bool, error)
+	DrainRequested(ctx context.Context, workerID string) bool
+	ListWorkers(ctx context.Context) ([]WorkerInfo, error)
+
+	Health(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ Queue = (*RedisQueue)(nil)
+	_ Queue = (*MemoryQueue)(nil)
+)
@@ -7,12 +7,14 @@ import (
 	"log"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/constants"
+	"github.com/redis/go-redis/v9"
 )
 
-// Queue maneja la cola de trabajos con Redis
-type Queue struct {
+// RedisQueue implementa Queue sobre Redis. Es el backend para despliegues con
+// varios procesos API y workers.
+type RedisQueue struct {
 	client *redis.Client
 	config *config.Config
 }
@@ -21,6 +23,9 @@ type Queue struct {
 type Job struct {
 	SubmissionID string    `json:"submission_id"`
 	Priority     int       `json:"priority"`
+	LanguageID   int       `json:"language_id,omitempty"`
+	Queue        string    `json:"queue,omitempty"`     // cola Redis asignada al encolar
+	QuotaKey     string    `json:"quota_key,omitempty"` // a quién se carga la CPU consumida
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -30,10 +35,11 @@ const (
 	QueueKeyLow      = "rojudger:queue:low"
 	ProcessingSetKey = "rojudger:processing"
 	StatsKey         = "rojudger:stats"
+	JobsKey          = "rojudger:jobs"
 )
 
-// NewQueue crea una nueva instancia del queue
-func NewQueue(cfg *config.Config) (*Queue, error) {
+// NewRedisQueue conecta con Redis y crea la cola
+func NewRedisQueue(cfg *config.Config) (*RedisQueue, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
 		Password: cfg.RedisPassword,
@@ -50,54 +56,94 @@ func NewQueue(cfg *config.Config) (*Queue, error) {
 
 	log.Println("Redis queue connected successfully")
 
-	return &Queue{
+	return &RedisQueue{
 		client: client,
 		config: cfg,
 	}, nil
 }
 
-// Enqueue añade un trabajo a la cola
-func (q *Queue) Enqueue(ctx context.Context, submissionID string, priority int) error {
-	job := Job{
-		SubmissionID: submissionID,
-		Priority:     priority,
-		CreatedAt:    time.Now(),
-	}
+// Enqueue añade un trabajo a la cola.
+// El trabajo va a la cola de su lenguaje si algún worker lo anuncia, o al pool general.
+func (q *RedisQueue) Enqueue(ctx context.Context, job Job) error {
+	job.CreatedAt = time.Now()
+	job.Queue = q.routeJob(ctx, job)
 
 	data, err := json.Marshal(job)
 	if err != nil {
 		return fmt.Errorf("failed to marshal job: %w", err)
 	}
 
-	// Seleccionar cola según prioridad
-	queueKey := QueueKeyDefault
-	if priority > 5 {
-		queueKey = QueueKeyHigh
-	} else if priority < 0 {
-		queueKey = QueueKeyLow
-	}
-
 	// Añadir a la cola (LPUSH para añadir al inicio)
-	if err := q.client.LPush(ctx, queueKey, data).Err(); err != nil {
+	if err := q.client.LPush(ctx, job.Queue, data).Err(); err != nil {
 		return fmt.Errorf("failed to enqueue job: %w", err)
 	}
 
+	// Guardar el job serializado para poder calcular su posición después
+	q.client.HSet(ctx, JobsKey, job.SubmissionID, data)
+	if job.Queue != queueKeyForPriority(job.Priority) {
+		q.client.SAdd(ctx, RoutedLanguagesKey, job.LanguageID)
+	}
+
 	// Incrementar contador de trabajos encolados
 	q.client.HIncrBy(ctx, StatsKey, "total_enqueued", 1)
 
-	log.Printf("Job enqueued: %s (priority: %d, queue: %s)", submissionID, priority, queueKey)
+	log.Printf("Job enqueued: %s (priority: %d, queue: %s)", job.SubmissionID, job.Priority, job.Queue)
+	return nil
+}
+
+// EnqueueBatch añade varios trabajos en un solo round-trip usando un pipeline
+func (q *RedisQueue) EnqueueBatch(ctx context.Context, jobs []Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	// Resolver la ruta una vez por lenguaje
+	routes := make(map[int]bool)
+	pipe := q.client.Pipeline()
+	now := time.Now()
+	for _, job := range jobs {
+		served, ok := routes[job.LanguageID]
+		if !ok {
+			served = job.LanguageID != 0 && q.languageServed(ctx, job.LanguageID)
+			routes[job.LanguageID] = served
+		}
+
+		job.CreatedAt = now
+		job.Queue = queueKeyForPriority(job.Priority)
+		if served {
+			job.Queue = queueKey(constants.GetQueueName(job.Priority), job.LanguageID)
+			pipe.SAdd(ctx, RoutedLanguagesKey, job.LanguageID)
+		}
+
+		data, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job: %w", err)
+		}
+
+		pipe.LPush(ctx, job.Queue, data)
+		pipe.HSet(ctx, JobsKey, job.SubmissionID, data)
+	}
+	pipe.HIncrBy(ctx, StatsKey, "total_enqueued", int64(len(jobs)))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue batch: %w", err)
+	}
+
+	log.Printf("Batch enqueued: %d jobs", len(jobs))
 	return nil
 }
 
-// Dequeue toma un trabajo de la cola (bloqueante)
-func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (*Job, error) {
+// Dequeue toma un trabajo de la cola (bloqueante).
+// Solo revisa las colas de los lenguajes indicados y, si generalPool es true, el pool general.
+func (q *RedisQueue) Dequeue(ctx context.Context, timeout time.Duration, languages []int, generalPool bool) (*Job, error) {
+	var routed []int
+	if generalPool {
+		routed = q.routedLanguages(ctx)
+	}
+
 	// BRPOP revisa múltiples colas por prioridad
 	// Orden: high → default → low
-	result, err := q.client.BRPop(ctx, timeout, 
-		QueueKeyHigh, 
-		QueueKeyDefault, 
-		QueueKeyLow,
-	).Result()
+	result, err := q.client.BRPop(ctx, timeout, dequeueKeys(languages, generalPool, routed)...).Result()
 
 	if err == redis.Nil {
 		// Timeout, no hay trabajos
@@ -115,6 +161,7 @@ func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (*Job, error
 	}
 
 	// Marcar como en procesamiento
+	q.client.HDel(ctx, JobsKey, job.SubmissionID)
 	q.client.SAdd(ctx, ProcessingSetKey, job.SubmissionID)
 	q.client.HIncrBy(ctx, StatsKey, "total_dequeued", 1)
 
@@ -122,39 +169,70 @@ func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (*Job, error
 	return &job, nil
 }
 
+// Requeue devuelve a la cola un trabajo que no pudo terminar (p.ej. al apagar un worker).
+// Se inserta por el extremo de consumo para que sea el próximo en ejecutarse.
+func (q *RedisQueue) Requeue(ctx context.Context, job Job) error {
+	job.Queue = q.routeJob(ctx, job)
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if err := q.client.RPush(ctx, job.Queue, data).Err(); err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	q.client.HSet(ctx, JobsKey, job.SubmissionID, data)
+	q.client.SRem(ctx, ProcessingSetKey, job.SubmissionID)
+	q.client.HIncrBy(ctx, StatsKey, "total_requeued", 1)
+
+	log.Printf("Job requeued: %s (queue: %s)", job.SubmissionID, job.Queue)
+	return nil
+}
+
 // MarkComplete marca un trabajo como completado
-func (q *Queue) MarkComplete(ctx context.Context, submissionID string) error {
+func (q *RedisQueue) MarkComplete(ctx context.Context, submissionID string) error {
 	// Remover del set de procesamiento
 	q.client.SRem(ctx, ProcessingSetKey, submissionID)
 	q.client.HIncrBy(ctx, StatsKey, "total_completed", 1)
-	
+	q.recordCompletion(ctx, submissionID)
+
 	log.Printf("Job marked complete: %s", submissionID)
 	return nil
 }
 
+// retryJob prepara un trabajo fallido para reencolarlo con baja prioridad,
+// conservando su lenguaje (ruteo) y a quién se carga la CPU (cuota)
+func retryJob(job Job) Job {
+	job.Priority = -1
+	job.Queue = ""
+	return job
+}
+
 // MarkFailed marca un trabajo como fallido y lo reencola (opcionalmente)
-func (q *Queue) MarkFailed(ctx context.Context, submissionID string, retry bool) error {
+func (q *RedisQueue) MarkFailed(ctx context.Context, job Job, retry bool) error {
 	// Remover del set de procesamiento
-	q.client.SRem(ctx, ProcessingSetKey, submissionID)
+	q.client.SRem(ctx, ProcessingSetKey, job.SubmissionID)
 	q.client.HIncrBy(ctx, StatsKey, "total_failed", 1)
+	q.recordCompletion(ctx, job.SubmissionID)
 
 	if retry {
-		// Reencolar con baja prioridad
-		return q.Enqueue(ctx, submissionID, -1)
+		return q.Enqueue(ctx, retryJob(job))
 	}
 
-	log.Printf("Job marked failed: %s (retry: %v)", submissionID, retry)
+	log.Printf("Job marked failed: %s (retry: %v)", job.SubmissionID, retry)
 	return nil
 }
 
 // GetStats obtiene estadísticas de la cola
-func (q *Queue) GetStats(ctx context.Context) (map[string]interface{}, error) {
+func (q *RedisQueue) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
-	// Tamaño de cada cola
-	highSize, _ := q.client.LLen(ctx, QueueKeyHigh).Result()
-	defaultSize, _ := q.client.LLen(ctx, QueueKeyDefault).Result()
-	lowSize, _ := q.client.LLen(ctx, QueueKeyLow).Result()
+	// Tamaño de cada nivel (pool general + colas por lenguaje)
+	highSize := q.levelLength(ctx, "high")
+	defaultSize := q.levelLength(ctx, "default")
+	lowSize := q.levelLength(ctx, "low")
 	processing, _ := q.client.SCard(ctx, ProcessingSetKey).Result()
 
 	stats["queue_high"] = highSize
@@ -173,20 +251,20 @@ func (q *Queue) GetStats(ctx context.Context) (map[string]interface{}, error) {
 }
 
 // QueueLength retorna el tamaño total de la cola
-func (q *Queue) QueueLength(ctx context.Context) (int64, error) {
-	high, _ := q.client.LLen(ctx, QueueKeyHigh).Result()
-	default_, _ := q.client.LLen(ctx, QueueKeyDefault).Result()
-	low, _ := q.client.LLen(ctx, QueueKeyLow).Result()
-	
+func (q *RedisQueue) QueueLength(ctx context.Context) (int64, error) {
+	high := q.levelLength(ctx, "high")
+	default_ := q.levelLength(ctx, "default")
+	low := q.levelLength(ctx, "low")
+
 	return high + default_ + low, nil
 }
 
 // Close cierra la conexión con Redis
-func (q *Queue) Close() error {
+func (q *RedisQueue) Close() error {
 	return q.client.Close()
 }
 
 // Health verifica el estado de Redis
-func (q *Queue) Health(ctx context.Context) error {
+func (q *RedisQueue) Health(ctx context.Context) error {
 	return q.client.Ping(ctx).Err()
 }
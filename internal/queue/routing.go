@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/constants"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// RoutedLanguagesKey contiene los IDs de lenguajes que tienen colas propias
+	RoutedLanguagesKey = "rojudger:queue:languages"
+	// languageWorkersPrefix + ID es un sorted set de workers que sirven ese
+	// lenguaje (score = expiración del heartbeat en ms)
+	languageWorkersPrefix = "rojudger:lang_workers:"
+)
+
+// priorityLevels lista los niveles de prioridad en el orden en que se consumen
+var priorityLevels = []string{"high", "default", "low"}
+
+// queueKey retorna la cola para un nivel de prioridad y lenguaje.
+// languageID = 0 corresponde al pool general.
+func queueKey(level string, languageID int) string {
+	if languageID == 0 {
+		return "rojudger:queue:" + level
+	}
+	return fmt.Sprintf("rojudger:queue:%s:lang:%d", level, languageID)
+}
+
+// queueKeyForPriority retorna la cola del pool general para una prioridad
+func queueKeyForPriority(priority int) string {
+	return queueKey(constants.GetQueueName(priority), 0)
+}
+
+// routeJob decide la cola de un trabajo: la cola de su lenguaje si algún worker
+// vivo lo anuncia, o el pool general en caso contrario
+func (q *RedisQueue) routeJob(ctx context.Context, job Job) string {
+	level := constants.GetQueueName(job.Priority)
+	if job.LanguageID == 0 || !q.languageServed(ctx, job.LanguageID) {
+		return queueKey(level, 0)
+	}
+	return queueKey(level, job.LanguageID)
+}
+
+// languageServed indica si algún worker vivo anuncia el lenguaje
+func (q *RedisQueue) languageServed(ctx context.Context, languageID int) bool {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	count, err := q.client.ZCount(ctx, languageWorkersPrefix+strconv.Itoa(languageID), now, "+inf").Result()
+	return err == nil && count > 0
+}
+
+// dequeueKeys arma la lista de colas que un worker consume, en orden de prioridad.
+// Dentro de cada nivel se revisan primero las colas de sus lenguajes y luego el pool
+// general. Los workers del pool general revisan al final las colas de los demás
+// lenguajes con cola propia (routed), para que no queden sin consumidor si el
+// worker que las anunciaba murió o se drenó.
+func dequeueKeys(languages []int, generalPool bool, routed []int) []string {
+	own := make(map[int]bool, len(languages))
+	for _, languageID := range languages {
+		own[languageID] = true
+	}
+
+	keys := make([]string, 0, len(priorityLevels)*(len(languages)+len(routed)+1))
+	for _, level := range priorityLevels {
+		for _, languageID := range languages {
+			keys = append(keys, queueKey(level, languageID))
+		}
+		if !generalPool {
+			continue
+		}
+		keys = append(keys, queueKey(level, 0))
+		for _, languageID := range routed {
+			if !own[languageID] {
+				keys = append(keys, queueKey(level, languageID))
+			}
+		}
+	}
+	return keys
+}
+
+// routedLanguages retorna los lenguajes que tuvieron colas propias
+func (q *RedisQueue) routedLanguages(ctx context.Context) []int {
+	members, _ := q.client.SMembers(ctx, RoutedLanguagesKey).Result()
+	languages := make([]int, 0, len(members))
+	for _, id := range members {
+		if languageID, err := strconv.Atoi(id); err == nil {
+			languages = append(languages, languageID)
+		}
+	}
+	sort.Ints(languages)
+	return languages
+}
+
+// levelKeys retorna todas las colas existentes (general + por lenguaje) de un nivel
+func (q *RedisQueue) levelKeys(ctx context.Context, level string) []string {
+	keys := []string{queueKey(level, 0)}
+	for _, languageID := range q.routedLanguages(ctx) {
+		keys = append(keys, queueKey(level, languageID))
+	}
+	return keys
+}
+
+// levelLength suma el tamaño de todas las colas de un nivel
+func (q *RedisQueue) levelLength(ctx context.Context, level string) int64 {
+	var total int64
+	for _, key := range q.levelKeys(ctx, level) {
+		n, _ := q.client.LLen(ctx, key).Result()
+		total += n
+	}
+	return total
+}
+
+// LanguageQueueLengths retorna los trabajos pendientes por lenguaje en colas propias
+func (q *RedisQueue) LanguageQueueLengths(ctx context.Context) map[string]int64 {
+	lengths := make(map[string]int64)
+
+	languages, _ := q.client.SMembers(ctx, RoutedLanguagesKey).Result()
+	for _, id := range languages {
+		languageID, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		var total int64
+		for _, level := range priorityLevels {
+			n, _ := q.client.LLen(ctx, queueKey(level, languageID)).Result()
+			total += n
+		}
+		if total > 0 {
+			lengths[id] = total
+		}
+	}
+	return lengths
+}
+
+// advertiseLanguages registra en los sets por lenguaje que el worker los sirve
+func advertiseLanguages(ctx context.Context, pipe redis.Pipeliner, info WorkerInfo, ttl time.Duration) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	expiresAt := float64(time.Now().Add(ttl).UnixMilli())
+	for _, languageID := range info.Languages {
+		key := languageWorkersPrefix + strconv.Itoa(languageID)
+		pipe.ZAdd(ctx, key, redis.Z{
+			Score:  expiresAt,
+			Member: info.ID,
+		})
+		// Limpiar workers cuyo heartbeat ya expiró
+		pipe.ZRemRangeByScore(ctx, key, "-inf", now)
+	}
+}
+
+// withdrawLanguages elimina al worker de los sets por lenguaje
+func withdrawLanguages(ctx context.Context, pipe redis.Pipeliner, info WorkerInfo) {
+	for _, languageID := range info.Languages {
+		pipe.ZRem(ctx, languageWorkersPrefix+strconv.Itoa(languageID), info.ID)
+	}
+}
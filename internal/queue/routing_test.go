@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGeneralPoolConsumesLanguageQueues(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	specialist := WorkerInfo{ID: "java-1", Languages: []int{62}}
+	if err := q.Heartbeat(ctx, specialist, time.Minute); err != nil {
+		t.Fatalf("Heartbeat: %v", err)
+	}
+	if err := q.Enqueue(ctx, Job{SubmissionID: "s1", LanguageID: 62}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// El especialista se fue: un worker del pool general debe tomar el trabajo
+	if err := q.UnregisterWorker(ctx, specialist); err != nil {
+		t.Fatalf("UnregisterWorker: %v", err)
+	}
+	job, err := q.Dequeue(ctx, 50*time.Millisecond, nil, true)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if job == nil || job.SubmissionID != "s1" {
+		t.Fatalf("expected s1 from the language queue, got %+v", job)
+	}
+}
+
+func TestDequeueKeysOrder(t *testing.T) {
+	keys := dequeueKeys([]int{62}, true, []int{62, 73})
+	want := []string{
+		"rojudger:queue:high:lang:62", "rojudger:queue:high", "rojudger:queue:high:lang:73",
+		"rojudger:queue:default:lang:62", "rojudger:queue:default", "rojudger:queue:default:lang:73",
+		"rojudger:queue:low:lang:62", "rojudger:queue:low", "rojudger:queue:low:lang:73",
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("key %d: got %s, want %s", i, keys[i], want[i])
+		}
+	}
+
+	// Sin pool general solo se revisan las colas propias
+	if keys := dequeueKeys([]int{62}, false, []int{62, 73}); len(keys) != 3 {
+		t.Fatalf("expected only own queues, got %v", keys)
+	}
+}
@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ScheduledKey es el sorted set de trabajos programados (score = run_at en ms)
+	ScheduledKey = "rojudger:scheduled"
+	// ScheduledJobsKey guarda los datos de cada trabajo programado
+	ScheduledJobsKey = "rojudger:scheduled:jobs"
+
+	// promoteBatchSize limita los trabajos promovidos por iteración
+	promoteBatchSize = 100
+	// promoteRetryDelay es la espera antes de reintentar un trabajo que no se pudo promover
+	promoteRetryDelay = 5 * time.Second
+)
+
+// Schedule programa un trabajo para que entre a la cola en runAt
+func (q *RedisQueue) Schedule(ctx context.Context, job Job, runAt time.Time) error {
+	job.CreatedAt = time.Now()
+	submissionID := job.SubmissionID
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.HSet(ctx, ScheduledJobsKey, submissionID, data)
+	pipe.ZAdd(ctx, ScheduledKey, redis.Z{
+		Score:  float64(runAt.UnixMilli()),
+		Member: submissionID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to schedule job: %w", err)
+	}
+
+	q.client.HIncrBy(ctx, StatsKey, "total_scheduled", 1)
+
+	log.Printf("Job scheduled: %s (priority: %d, run_at: %s)", submissionID, job.Priority, runAt.Format(time.RFC3339))
+	return nil
+}
+
+// CancelScheduled elimina un trabajo programado antes de que entre a la cola.
+// Retorna false si el trabajo ya no estaba programado (ya se promovió o no existe).
+func (q *RedisQueue) CancelScheduled(ctx context.Context, submissionID string) (bool, error) {
+	removed, err := q.client.ZRem(ctx, ScheduledKey, submissionID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel scheduled job: %w", err)
+	}
+	if removed == 0 {
+		return false, nil
+	}
+
+	q.client.HDel(ctx, ScheduledJobsKey, submissionID)
+
+	log.Printf("Scheduled job cancelled: %s", submissionID)
+	return true, nil
+}
+
+// PromoteDue mueve los trabajos cuyo run_at ya pasó a las colas de prioridad.
+// onPromote se invoca antes de encolar cada trabajo (p.ej. para actualizar su estado).
+func (q *RedisQueue) PromoteDue(ctx context.Context, onPromote func(job Job) error) (int, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	ids, err := q.client.ZRangeByScore(ctx, ScheduledKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   now,
+		Count: promoteBatchSize,
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due jobs: %w", err)
+	}
+
+	promoted := 0
+	for _, id := range ids {
+		// ZREM es atómico: solo un promotor se queda con cada trabajo
+		removed, err := q.client.ZRem(ctx, ScheduledKey, id).Result()
+		if err != nil {
+			return promoted, fmt.Errorf("failed to claim scheduled job: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+
+		// Si algo falla el trabajo vuelve al sorted set: perderlo dejaría la
+		// submission en scheduled para siempre
+		data, err := q.client.HGet(ctx, ScheduledJobsKey, id).Result()
+		if err != nil {
+			q.retryScheduled(ctx, id)
+			return promoted, fmt.Errorf("failed to load scheduled job %s: %w", id, err)
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			q.retryScheduled(ctx, id)
+			return promoted, fmt.Errorf("failed to unmarshal scheduled job %s: %w", id, err)
+		}
+
+		if onPromote != nil {
+			if err := onPromote(job); err != nil {
+				log.Printf("Failed to promote scheduled job %s: %v", id, err)
+				q.retryScheduled(ctx, id)
+				continue
+			}
+		}
+
+		if err := q.Enqueue(ctx, job); err != nil {
+			q.retryScheduled(ctx, id)
+			return promoted, err
+		}
+		q.client.HDel(ctx, ScheduledJobsKey, id)
+		promoted++
+	}
+
+	return promoted, nil
+}
+
+// retryScheduled vuelve a programar un trabajo reclamado por PromoteDue para
+// dentro de promoteRetryDelay
+func (q *RedisQueue) retryScheduled(ctx context.Context, submissionID string) {
+	err := q.client.ZAdd(ctx, ScheduledKey, redis.Z{
+		Score:  float64(time.Now().Add(promoteRetryDelay).UnixMilli()),
+		Member: submissionID,
+	}).Err()
+	if err != nil {
+		log.Printf("⚠️  Failed to reschedule job %s: %v", submissionID, err)
+	}
+}
+
+// RunScheduler ejecuta PromoteDue periódicamente hasta que se cancele el contexto
+func (q *RedisQueue) RunScheduler(ctx context.Context, interval time.Duration, onPromote func(job Job) error) {
+	runScheduler(ctx, interval, func() (int, error) {
+		return q.PromoteDue(ctx, onPromote)
+	})
+}
+
+// runScheduler llama a promote cada interval hasta que se cancele el contexto
+func runScheduler(ctx context.Context, interval time.Duration, promote func() (int, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			promoted, err := promote()
+			if err != nil {
+				log.Printf("Scheduler error: %v", err)
+				continue
+			}
+			if promoted > 0 {
+				log.Printf("Scheduler promoted %d job(s)", promoted)
+			}
+		}
+	}
+}
+
+// ScheduledCount retorna la cantidad de trabajos programados
+func (q *RedisQueue) ScheduledCount(ctx context.Context) (int64, error) {
+	return q.client.ZCard(ctx, ScheduledKey).Result()
+}
@@ -3,35 +3,53 @@ package queue
 import (
 	"context"
 	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// CompletionsKey guarda los trabajos terminados recientemente (score = timestamp)
+	CompletionsKey = "rojudger:stats:completions"
+
+	// ThroughputWindow es la ventana usada para calcular el throughput
+	ThroughputWindow = 5 * time.Minute
 )
 
 // Stats representa las estadísticas de la cola
 type Stats struct {
-	QueueHigh      int64  `json:"queue_high"`
-	QueueDefault   int64  `json:"queue_default"`
-	QueueLow       int64  `json:"queue_low"`
-	Processing     int64  `json:"processing"`
-	TotalPending   int64  `json:"total_pending"`
-	TotalEnqueued  int64  `json:"total_enqueued"`
-	TotalDequeued  int64  `json:"total_dequeued"`
-	TotalCompleted int64  `json:"total_completed"`
-	TotalFailed    int64  `json:"total_failed"`
+	QueueHigh           int64            `json:"queue_high"`
+	QueueDefault        int64            `json:"queue_default"`
+	QueueLow            int64            `json:"queue_low"`
+	Processing          int64            `json:"processing"`
+	TotalPending        int64            `json:"total_pending"`
+	QueueByLanguage     map[string]int64 `json:"queue_by_language,omitempty"` // colas dedicadas por lenguaje
+	Scheduled           int64            `json:"scheduled"`
+	TotalEnqueued       int64            `json:"total_enqueued"`
+	TotalDequeued       int64            `json:"total_dequeued"`
+	TotalCompleted      int64            `json:"total_completed"`
+	TotalFailed         int64            `json:"total_failed"`
+	TotalScheduled      int64            `json:"total_scheduled"`
+	TotalRequeued       int64            `json:"total_requeued"`
+	ThroughputPerMinute float64          `json:"throughput_per_minute"`
 }
 
 // GetStatsTyped retorna estadísticas con tipos correctos
-func (q *Queue) GetStatsTyped(ctx context.Context) (*Stats, error) {
+func (q *RedisQueue) GetStatsTyped(ctx context.Context) (*Stats, error) {
 	stats := &Stats{}
 
 	// Tamaño de cada cola
-	stats.QueueHigh, _ = q.client.LLen(ctx, QueueKeyHigh).Result()
-	stats.QueueDefault, _ = q.client.LLen(ctx, QueueKeyDefault).Result()
-	stats.QueueLow, _ = q.client.LLen(ctx, QueueKeyLow).Result()
+	stats.QueueHigh = q.levelLength(ctx, "high")
+	stats.QueueDefault = q.levelLength(ctx, "default")
+	stats.QueueLow = q.levelLength(ctx, "low")
+	stats.QueueByLanguage = q.LanguageQueueLengths(ctx)
 	stats.Processing, _ = q.client.SCard(ctx, ProcessingSetKey).Result()
 	stats.TotalPending = stats.QueueHigh + stats.QueueDefault + stats.QueueLow
+	stats.Scheduled, _ = q.client.ZCard(ctx, ScheduledKey).Result()
 
 	// Contadores totales (convertir strings a int64)
 	allStats, _ := q.client.HGetAll(ctx, StatsKey).Result()
-	
+
 	if val, ok := allStats["total_enqueued"]; ok {
 		stats.TotalEnqueued, _ = strconv.ParseInt(val, 10, 64)
 	}
@@ -44,6 +62,40 @@ func (q *Queue) GetStatsTyped(ctx context.Context) (*Stats, error) {
 	if val, ok := allStats["total_failed"]; ok {
 		stats.TotalFailed, _ = strconv.ParseInt(val, 10, 64)
 	}
+	if val, ok := allStats["total_scheduled"]; ok {
+		stats.TotalScheduled, _ = strconv.ParseInt(val, 10, 64)
+	}
+	if val, ok := allStats["total_requeued"]; ok {
+		stats.TotalRequeued, _ = strconv.ParseInt(val, 10, 64)
+	}
+
+	// Throughput reciente
+	throughput, _ := q.Throughput(ctx)
+	stats.ThroughputPerMinute = throughput * 60
 
 	return stats, nil
 }
+
+// recordCompletion registra un trabajo terminado para el cálculo de throughput
+func (q *RedisQueue) recordCompletion(ctx context.Context, submissionID string) {
+	now := time.Now()
+	q.client.ZAdd(ctx, CompletionsKey, redis.Z{
+		Score:  float64(now.UnixMilli()),
+		Member: submissionID,
+	})
+
+	// Descartar registros fuera de la ventana
+	cutoff := now.Add(-ThroughputWindow).UnixMilli()
+	q.client.ZRemRangeByScore(ctx, CompletionsKey, "-inf", strconv.FormatInt(cutoff, 10))
+}
+
+// Throughput retorna los trabajos terminados por segundo dentro de la ventana reciente
+func (q *RedisQueue) Throughput(ctx context.Context) (float64, error) {
+	cutoff := time.Now().Add(-ThroughputWindow).UnixMilli()
+	count, err := q.client.ZCount(ctx, CompletionsKey, strconv.FormatInt(cutoff, 10), "+inf").Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(count) / ThroughputWindow.Seconds(), nil
+}
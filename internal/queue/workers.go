@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// WorkersSetKey contiene los IDs de los workers registrados
+	WorkersSetKey = "rojudger:workers"
+	// workerKeyPrefix + ID guarda la información de cada worker (con TTL)
+	workerKeyPrefix = "rojudger:worker:"
+	// drainKeySuffix marca que un admin pidió drenar el worker
+	drainKeySuffix = ":drain"
+)
+
+// WorkerInfo describe un proceso worker vivo
+type WorkerInfo struct {
+	ID            string    `json:"id"`
+	Hostname      string    `json:"hostname"`
+	Version       string    `json:"version"`
+	Capacity      int       `json:"capacity"`     // ejecuciones concurrentes
+	Languages     []int     `json:"languages"`    // IDs de lenguajes que puede ejecutar
+	GeneralPool   bool      `json:"general_pool"` // también consume el pool general
+	Draining      bool      `json:"draining"`     // no toma trabajos nuevos, está apagándose
+	Running       []string  `json:"running"`      // submissions en ejecución
+	StartedAt     time.Time `json:"started_at"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+}
+
+// WorkerSummary resume la capacidad de los workers vivos
+type WorkerSummary struct {
+	Alive    int `json:"alive"`
+	Capacity int `json:"capacity"`
+	Busy     int `json:"busy"`
+}
+
+// Heartbeat registra (o refresca) un worker. Si no hay otro heartbeat antes de
+// ttl, el registro expira y el worker se considera muerto.
+func (q *RedisQueue) Heartbeat(ctx context.Context, info WorkerInfo, ttl time.Duration) error {
+	info.LastHeartbeat = time.Now()
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal worker info: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.Set(ctx, workerKeyPrefix+info.ID, data, ttl)
+	pipe.SAdd(ctx, WorkersSetKey, info.ID)
+	advertiseLanguages(ctx, pipe, info, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	return nil
+}
+
+// UnregisterWorker elimina un worker del registro (apagado ordenado)
+func (q *RedisQueue) UnregisterWorker(ctx context.Context, info WorkerInfo) error {
+	pipe := q.client.TxPipeline()
+	pipe.Del(ctx, workerKeyPrefix+info.ID, workerKeyPrefix+info.ID+drainKeySuffix)
+	pipe.SRem(ctx, WorkersSetKey, info.ID)
+	withdrawLanguages(ctx, pipe, info)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to unregister worker: %w", err)
+	}
+	return nil
+}
+
+// RequestDrain pide a un worker vivo que deje de tomar trabajos y se apague.
+// Retorna false si el worker no está registrado.
+func (q *RedisQueue) RequestDrain(ctx context.Context, workerID string) (bool, error) {
+	exists, err := q.client.Exists(ctx, workerKeyPrefix+workerID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worker: %w", err)
+	}
+	if exists == 0 {
+		return false, nil
+	}
+
+	if err := q.client.Set(ctx, workerKeyPrefix+workerID+drainKeySuffix, 1, time.Hour).Err(); err != nil {
+		return false, fmt.Errorf("failed to request drain: %w", err)
+	}
+	return true, nil
+}
+
+// DrainRequested indica si un admin pidió drenar el worker
+func (q *RedisQueue) DrainRequested(ctx context.Context, workerID string) bool {
+	exists, err := q.client.Exists(ctx, workerKeyPrefix+workerID+drainKeySuffix).Result()
+	return err == nil && exists > 0
+}
+
+// ListWorkers retorna los workers vivos y limpia los que ya expiraron
+func (q *RedisQueue) ListWorkers(ctx context.Context) ([]WorkerInfo, error) {
+	ids, err := q.client.SMembers(ctx, WorkersSetKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", err)
+	}
+
+	workers := make([]WorkerInfo, 0, len(ids))
+	for _, id := range ids {
+		data, err := q.client.Get(ctx, workerKeyPrefix+id).Result()
+		if err == redis.Nil {
+			// El heartbeat expiró: el worker está muerto
+			q.client.SRem(ctx, WorkersSetKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get worker %s: %w", id, err)
+		}
+
+		var info WorkerInfo
+		if err := json.Unmarshal([]byte(data), &info); err != nil {
+			continue
+		}
+		workers = append(workers, info)
+	}
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].StartedAt.Before(workers[j].StartedAt)
+	})
+
+	return workers, nil
+}
+
+// SummarizeWorkers calcula totales de capacidad sobre los workers vivos
+func SummarizeWorkers(workers []WorkerInfo) WorkerSummary {
+	summary := WorkerSummary{Alive: len(workers)}
+	for _, w := range workers {
+		summary.Capacity += w.Capacity
+		summary.Busy += len(w.Running)
+	}
+	return summary
+}
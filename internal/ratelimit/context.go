@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"github.com/RobertoRochaT/rojudger/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// cpuUsageKey guarda en el gin.Context los segundos de CPU consumidos por la petición
+const cpuUsageKey = "rojudger.cpu_seconds"
+
+// BucketKey identifica al cliente para el rate limiting: su API key, el usuario
+// del JWT o, sin credenciales, su IP
+func BucketKey(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil {
+		if subject := p.Subject(); subject != "" {
+			return subject
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// QuotaKey identifica a quién se cargan las cuotas de ejecución: el tenant del
+// principal si tiene, si no su API key/usuario, o la IP
+func QuotaKey(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil && p.TenantID != "" {
+		return "tenant:" + p.TenantID
+	}
+	return BucketKey(c)
+}
+
+// PlanName retorna el plan del principal ("" = default)
+func PlanName(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil {
+		return p.Plan
+	}
+	return ""
+}
+
+// QuotaPlanName retorna el plan cuyas cuotas se aplican a QuotaKey: el del tenant
+// si el principal tiene, para que todas sus keys y usuarios compartan límite
+func QuotaPlanName(c *gin.Context) string {
+	if p := auth.FromContext(c); p != nil && p.TenantID != "" {
+		return p.TenantPlan
+	}
+	return PlanName(c)
+}
+
+// AddCPUUsage registra CPU consumida durante la petición (ejecución síncrona);
+// el middleware de rate limiting la carga a la cuota al terminar
+func AddCPUUsage(c *gin.Context, seconds float64) {
+	c.Set(cpuUsageKey, CPUUsage(c)+seconds)
+}
+
+// CPUUsage retorna la CPU registrada con AddCPUUsage
+func CPUUsage(c *gin.Context) float64 {
+	return c.GetFloat64(cpuUsageKey)
+}
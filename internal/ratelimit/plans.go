@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultPlan es el plan de las API keys y usuarios sin plan asignado
+const DefaultPlan = "default"
+
+// Plan define los límites de un cliente. Un valor 0 significa sin límite.
+type Plan struct {
+	Name               string  `json:"name"`
+	RequestsPerMinute  float64 `json:"requests_per_minute"` // recarga del token bucket
+	Burst              int     `json:"burst"`               // capacidad del token bucket
+	DailySubmissions   int64   `json:"daily_submissions"`
+	MonthlySubmissions int64   `json:"monthly_submissions"`
+	DailyCPUSeconds    float64 `json:"daily_cpu_seconds"`
+	MonthlyCPUSeconds  float64 `json:"monthly_cpu_seconds"`
+}
+
+// LoadPlans lee los planes de un archivo JSON (un arreglo de planes). El plan
+// "default" es fallback salvo que el archivo lo redefina.
+func LoadPlans(path string, fallback Plan) (map[string]Plan, error) {
+	fallback.Name = DefaultPlan
+	plans := map[string]Plan{DefaultPlan: normalize(fallback)}
+	if path == "" {
+		return plans, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plans file: %w", err)
+	}
+
+	var list []Plan
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse plans file: %w", err)
+	}
+
+	for _, plan := range list {
+		if plan.Name == "" {
+			return nil, fmt.Errorf("plan without name in %s", path)
+		}
+		if plan.RequestsPerMinute < 0 || plan.Burst < 0 {
+			return nil, fmt.Errorf("plan %s: rate limits must not be negative", plan.Name)
+		}
+		plans[plan.Name] = normalize(plan)
+	}
+	return plans, nil
+}
+
+// normalize asegura que un plan con tasa tenga capacidad para al menos una petición
+func normalize(plan Plan) Plan {
+	if plan.RequestsPerMinute > 0 && plan.Burst < 1 {
+		plan.Burst = 1
+	}
+	return plan
+}
@@ -0,0 +1,264 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	bucketKeyPrefix = "rojudger:ratelimit:"
+	quotaKeyPrefix  = "rojudger:quota:"
+
+	// Los contadores viven un poco más que su periodo por diferencias de reloj
+	dailyTTL   = 48 * time.Hour
+	monthlyTTL = 32 * 24 * time.Hour
+)
+
+// Nombres de las cuotas, usados en las respuestas 429
+const (
+	QuotaDailySubmissions   = "daily_submissions"
+	QuotaMonthlySubmissions = "monthly_submissions"
+	QuotaDailyCPU           = "daily_cpu_seconds"
+	QuotaMonthlyCPU         = "monthly_cpu_seconds"
+)
+
+// tokenBucketScript recarga el bucket según el tiempo transcurrido (reloj de Redis,
+// común a todas las instancias del API) y consume cost tokens si alcanzan.
+// Retorna {permitido, tokens restantes, ms hasta poder reintentar, ms hasta llenarse}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+else
+	wait = math.ceil((cost - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', string.format('%.0f', now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, math.floor(tokens), wait, math.ceil((burst - tokens) / rate)}
+`)
+
+// reserveScript verifica las cuotas y, si no se superan, suma n submissions a los
+// contadores del día y del mes. Retorna {cuota superada, uso actual} o {"", ""}.
+var reserveScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+local checks = {
+	{KEYS[3], tonumber(ARGV[4]), 0, 'daily_cpu_seconds'},
+	{KEYS[4], tonumber(ARGV[5]), 0, 'monthly_cpu_seconds'},
+	{KEYS[1], tonumber(ARGV[2]), n, 'daily_submissions'},
+	{KEYS[2], tonumber(ARGV[3]), n, 'monthly_submissions'},
+}
+for _, check in ipairs(checks) do
+	local used = tonumber(redis.call('GET', check[1]) or '0')
+	if check[2] > 0 and (used + check[3] > check[2] or (check[3] == 0 and used >= check[2])) then
+		return {check[4], tostring(used)}
+	end
+end
+
+redis.call('INCRBY', KEYS[1], n)
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+redis.call('INCRBY', KEYS[2], n)
+redis.call('EXPIRE', KEYS[2], ARGV[7])
+return {'', ''}
+`)
+
+// Limiter aplica rate limiting (token bucket por cliente) y cuotas de ejecución
+// (por tenant) usando Redis, para que todas las instancias del API compartan el estado
+type Limiter struct {
+	client *redis.Client
+	plans  map[string]Plan
+}
+
+// Decision es el resultado de consumir un token del bucket
+type Decision struct {
+	Allowed    bool
+	Limit      int           // capacidad del bucket (0 = el plan no limita la tasa)
+	Remaining  int           // tokens que quedan
+	RetryAfter time.Duration // espera antes de reintentar (si no se permitió)
+	Reset      time.Duration // tiempo hasta que el bucket esté lleno
+}
+
+// QuotaExceeded describe la cuota que impidió crear submissions
+type QuotaExceeded struct {
+	Quota      string
+	Limit      float64
+	Used       float64
+	RetryAfter time.Duration // hasta que empiece el siguiente periodo
+}
+
+// NewLimiter conecta a Redis y carga los planes configurados
+func NewLimiter(cfg *config.Config) (*Limiter, error) {
+	plans, err := LoadPlans(cfg.RateLimitPlansFile, Plan{
+		RequestsPerMinute:  cfg.RateLimitRequestsPerMinute,
+		Burst:              cfg.RateLimitBurst,
+		DailySubmissions:   int64(cfg.QuotaDailySubmissions),
+		MonthlySubmissions: int64(cfg.QuotaMonthlySubmissions),
+		DailyCPUSeconds:    cfg.QuotaDailyCPUSeconds,
+		MonthlyCPUSeconds:  cfg.QuotaMonthlyCPUSeconds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.GetRedisAddr(),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	log.Printf("Rate limiter connected (%d plans)", len(plans))
+
+	return &Limiter{
+		client: client,
+		plans:  plans,
+	}, nil
+}
+
+// Plan retorna el plan por nombre; los nombres desconocidos usan el plan default
+func (l *Limiter) Plan(name string) Plan {
+	if plan, ok := l.plans[name]; ok {
+		return plan
+	}
+	return l.plans[DefaultPlan]
+}
+
+// Allow consume un token del bucket del cliente
+func (l *Limiter) Allow(ctx context.Context, key string, plan Plan) (*Decision, error) {
+	if plan.RequestsPerMinute <= 0 {
+		return &Decision{Allowed: true}, nil
+	}
+
+	ratePerMs := plan.RequestsPerMinute / float64(time.Minute/time.Millisecond)
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{bucketKeyPrefix + key},
+		ratePerMs, plan.Burst, 1).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	return &Decision{
+		Allowed:    values[0] == 1,
+		Limit:      plan.Burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// ReserveSubmissions descuenta n submissions de las cuotas del tenant. Retorna la
+// cuota superada (sin descontar nada) o nil si se pueden crear.
+func (l *Limiter) ReserveSubmissions(ctx context.Context, subject string, plan Plan, n int, now time.Time) (*QuotaExceeded, error) {
+	keys := quotaKeys(subject, now)
+	values, err := reserveScript.Run(ctx, l.client, keys[:],
+		n, plan.DailySubmissions, plan.MonthlySubmissions,
+		plan.DailyCPUSeconds, plan.MonthlyCPUSeconds,
+		int(dailyTTL.Seconds()), int(monthlyTTL.Seconds())).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+	if values[0] == "" {
+		return nil, nil
+	}
+
+	used, _ := strconv.ParseFloat(values[1], 64)
+	exceeded := &QuotaExceeded{Quota: values[0], Used: used}
+	switch exceeded.Quota {
+	case QuotaDailySubmissions:
+		exceeded.Limit = float64(plan.DailySubmissions)
+		exceeded.RetryAfter = untilNextDay(now)
+	case QuotaMonthlySubmissions:
+		exceeded.Limit = float64(plan.MonthlySubmissions)
+		exceeded.RetryAfter = untilNextMonth(now)
+	case QuotaDailyCPU:
+		exceeded.Limit = plan.DailyCPUSeconds
+		exceeded.RetryAfter = untilNextDay(now)
+	case QuotaMonthlyCPU:
+		exceeded.Limit = plan.MonthlyCPUSeconds
+		exceeded.RetryAfter = untilNextMonth(now)
+	}
+	return exceeded, nil
+}
+
+// ReleaseSubmissions devuelve n submissions reservadas que no se llegaron a crear
+func (l *Limiter) ReleaseSubmissions(ctx context.Context, subject string, n int, now time.Time) error {
+	keys := quotaKeys(subject, now)
+	pipe := l.client.Pipeline()
+	pipe.DecrBy(ctx, keys[0], int64(n))
+	pipe.DecrBy(ctx, keys[1], int64(n))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to release quota: %w", err)
+	}
+	return nil
+}
+
+// RecordCPU suma segundos de CPU consumidos al tenant
+func (l *Limiter) RecordCPU(ctx context.Context, subject string, seconds float64, now time.Time) error {
+	if subject == "" || seconds <= 0 {
+		return nil
+	}
+	keys := quotaKeys(subject, now)
+	pipe := l.client.Pipeline()
+	pipe.IncrByFloat(ctx, keys[2], seconds)
+	pipe.Expire(ctx, keys[2], dailyTTL)
+	pipe.IncrByFloat(ctx, keys[3], seconds)
+	pipe.Expire(ctx, keys[3], monthlyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record cpu usage: %w", err)
+	}
+	return nil
+}
+
+// Close cierra la conexión a Redis
+func (l *Limiter) Close() error {
+	return l.client.Close()
+}
+
+// quotaKeys retorna los contadores del periodo actual (UTC):
+// submissions del día, del mes, CPU del día y del mes
+func quotaKeys(subject string, now time.Time) [4]string {
+	now = now.UTC()
+	prefix := quotaKeyPrefix + subject + ":"
+	day := now.Format("2006-01-02")
+	month := now.Format("2006-01")
+	return [4]string{
+		prefix + "submissions:" + day,
+		prefix + "submissions:" + month,
+		prefix + "cpu:" + day,
+		prefix + "cpu:" + month,
+	}
+}
+
+func untilNextDay(now time.Time) time.Duration {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
+
+func untilNextMonth(now time.Time) time.Duration {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
@@ -0,0 +1,87 @@
+package retention
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// Acciones registradas en el archivo
+const (
+	ActionPurgePayload = "purge_payload" // se borraron código, stdin y salidas
+	ActionDelete       = "delete"        // se borró la submission y sus logs de webhooks
+)
+
+// ArchiveRecord es una línea del archivo JSONL: lo que había antes de purgar
+type ArchiveRecord struct {
+	Action      string              `json:"action"`
+	ArchivedAt  time.Time           `json:"archived_at"`
+	Submission  models.Submission   `json:"submission"`
+	WebhookLogs []models.WebhookLog `json:"webhook_logs,omitempty"`
+}
+
+// archive escribe los registros de una ejecución en un archivo .jsonl.gz.
+// El archivo se crea con el primer registro, así las ejecuciones sin cambios
+// no dejan archivos vacíos.
+type archive struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+// newArchive prepara el archivo de la ejecución dentro de dir
+func newArchive(dir string, run *models.RetentionRun) *archive {
+	name := fmt.Sprintf("retention-%s-%d.jsonl.gz", run.StartedAt.UTC().Format("20060102T150405Z"), run.ID)
+	return &archive{path: filepath.Join(dir, name)}
+}
+
+// write agrega los registros y los vuelca al disco antes de que se borren de la base
+func (a *archive) write(records []ArchiveRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	if a.file == nil {
+		if err := os.MkdirAll(filepath.Dir(a.path), 0o750); err != nil {
+			return fmt.Errorf("failed to create archive dir: %w", err)
+		}
+		file, err := os.OpenFile(a.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+		if err != nil {
+			return fmt.Errorf("failed to create archive: %w", err)
+		}
+		a.file = file
+		a.gz = gzip.NewWriter(file)
+		a.enc = json.NewEncoder(a.gz)
+	}
+
+	for _, record := range records {
+		if err := a.enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	if err := a.gz.Flush(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive: %w", err)
+	}
+	return nil
+}
+
+// close termina el stream gzip y cierra el archivo
+func (a *archive) close() error {
+	if a.file == nil {
+		return nil
+	}
+	if err := a.gz.Close(); err != nil {
+		a.file.Close()
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+	return a.file.Close()
+}
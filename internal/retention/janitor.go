@@ -0,0 +1,351 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// ErrRunInProgress indica que ya hay una ejecución en curso (en este u otro proceso)
+var ErrRunInProgress = errors.New("a retention run is already in progress")
+
+// staleRunAfter es cuánto puede seguir "running" una ejecución antes de darla por
+// abandonada (el proceso que la corría murió sin cerrarla)
+const staleRunAfter = 6 * time.Hour
+
+// Quién disparó una ejecución
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Janitor aplica las políticas de retención: borra submissions vencidas (con sus
+// logs de webhooks) y purga el código y las salidas de las que solo vencieron
+// en payload, en lotes y opcionalmente archivándolas antes.
+type Janitor struct {
+	cfg      *config.Config
+	db       database.Store
+	policies Policies
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJanitor carga las políticas (regla general de la configuración más el
+// archivo RETENTION_POLICIES_FILE)
+func NewJanitor(cfg *config.Config, db database.Store) (*Janitor, error) {
+	if cfg.RetentionPayloadDays < 0 || cfg.RetentionMetadataDays < 0 {
+		return nil, fmt.Errorf("retention days must not be negative")
+	}
+
+	policies, err := LoadPolicies(cfg.RetentionPoliciesFile, Policy{
+		PayloadDays:  cfg.RetentionPayloadDays,
+		MetadataDays: cfg.RetentionMetadataDays,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Janitor{cfg: cfg, db: db, policies: policies, ctx: ctx, cancel: cancel}, nil
+}
+
+// Policies retorna las políticas vigentes
+func (j *Janitor) Policies() Policies {
+	return j.policies
+}
+
+// Start corre el janitor cada RETENTION_INTERVAL si RETENTION_ENABLED=true
+func (j *Janitor) Start() {
+	if !j.cfg.RetentionEnabled {
+		return
+	}
+
+	interval := j.cfg.RetentionInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	log.Printf("✓ Retention janitor enabled (every %v)", interval)
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-ticker.C:
+				run, err := j.begin(TriggerSchedule)
+				if errors.Is(err, ErrRunInProgress) {
+					continue
+				}
+				if err != nil {
+					log.Printf("Retention janitor: %v", err)
+					continue
+				}
+				j.execute(run)
+			}
+		}
+	}()
+}
+
+// Trigger lanza una ejecución en segundo plano y la retorna recién registrada
+func (j *Janitor) Trigger() (*models.RetentionRun, error) {
+	run, err := j.begin(TriggerManual)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := *run
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		j.execute(run)
+	}()
+	return &snapshot, nil
+}
+
+// Close interrumpe la ejecución en curso (queda registrada como fallida) y espera a que termine
+func (j *Janitor) Close() {
+	j.cancel()
+	j.wg.Wait()
+}
+
+// begin registra una ejecución nueva. Antes cierra las que quedaron colgadas.
+func (j *Janitor) begin(triggeredBy string) (*models.RetentionRun, error) {
+	if j.ctx.Err() != nil {
+		return nil, fmt.Errorf("retention janitor is shutting down")
+	}
+
+	now := time.Now()
+	if abandoned, err := j.db.AbandonRetentionRuns(now.Add(-staleRunAfter)); err != nil {
+		log.Printf("Retention janitor: %v", err)
+	} else if abandoned > 0 {
+		log.Printf("Retention janitor: marked %d stale run(s) as failed", abandoned)
+	}
+
+	run := &models.RetentionRun{TriggeredBy: triggeredBy, StartedAt: now}
+	started, err := j.db.StartRetentionRun(run)
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		return nil, ErrRunInProgress
+	}
+	return run, nil
+}
+
+// execute aplica todas las reglas y guarda el resultado de la ejecución
+func (j *Janitor) execute(run *models.RetentionRun) {
+	log.Printf("🧹 Retention run #%d started (%s)", run.ID, run.TriggeredBy)
+
+	var arch *archive
+	if j.cfg.RetentionArchiveDir != "" {
+		arch = newArchive(j.cfg.RetentionArchiveDir, run)
+	}
+
+	err := j.applyPolicies(run, arch)
+	if arch != nil {
+		if closeErr := arch.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = models.RetentionCompleted
+	if err != nil {
+		run.Status = models.RetentionFailed
+		run.Error = err.Error()
+	}
+	if err := j.db.UpdateRetentionRun(run); err != nil {
+		log.Printf("Retention janitor: %v", err)
+	}
+
+	if run.Status == models.RetentionFailed {
+		log.Printf("❌ Retention run #%d failed: %s", run.ID, run.Error)
+		return
+	}
+	log.Printf("✅ Retention run #%d completed: %d deleted, %d payloads purged, %d archived",
+		run.ID, run.SubmissionsDeleted, run.PayloadsPurged, run.Archived)
+}
+
+// applyPolicies recorre cada estado final: primero los tenants con reglas
+// propias y después el resto, cada grupo con la regla que le corresponde
+func (j *Janitor) applyPolicies(run *models.RetentionRun, arch *archive) error {
+	tenants := j.policies.tenants()
+	// now también es el payload_purged_at que se guarda: en UTC como el resto
+	now := time.Now().UTC()
+
+	for _, status := range models.FinishedStatuses {
+		for _, tenantID := range tenants {
+			scope := database.RetentionScope{Status: status, TenantID: tenantID}
+			if err := j.applyPolicy(run, arch, scope, j.policies.Resolve(tenantID, status), now); err != nil {
+				return err
+			}
+		}
+
+		scope := database.RetentionScope{Status: status, ExcludeTenants: tenants}
+		if err := j.applyPolicy(run, arch, scope, j.policies.Resolve("", status), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPolicy borra primero lo vencido por completo y después purga los
+// payloads vencidos de lo que queda
+func (j *Janitor) applyPolicy(run *models.RetentionRun, arch *archive, scope database.RetentionScope, policy Policy, now time.Time) error {
+	if policy.MetadataDays > 0 {
+		before := now.AddDate(0, 0, -policy.MetadataDays)
+		err := j.inBatches(run, func() ([]string, error) {
+			return j.db.ExpiredSubmissionIDs(scope, before, j.batchSize())
+		}, func(ids []string) error {
+			return j.deleteBatch(run, arch, ids)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.PayloadDays > 0 && (policy.MetadataDays == 0 || policy.PayloadDays < policy.MetadataDays) {
+		before := now.AddDate(0, 0, -policy.PayloadDays)
+		err := j.inBatches(run, func() ([]string, error) {
+			return j.db.ExpiredPayloadIDs(scope, before, j.batchSize())
+		}, func(ids []string) error {
+			return j.purgeBatch(run, arch, ids, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inBatches procesa lotes hasta que uno venga incompleto, guardando el progreso
+// después de cada uno
+func (j *Janitor) inBatches(run *models.RetentionRun, next func() ([]string, error), process func(ids []string) error) error {
+	for {
+		if j.ctx.Err() != nil {
+			return fmt.Errorf("interrupted by shutdown")
+		}
+
+		ids, err := next()
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := process(ids); err != nil {
+			return err
+		}
+		if err := j.db.UpdateRetentionRun(run); err != nil {
+			log.Printf("Retention janitor: %v", err)
+		}
+
+		if len(ids) < j.batchSize() {
+			return nil
+		}
+	}
+}
+
+// deleteBatch archiva (si corresponde) y borra un lote de submissions
+func (j *Janitor) deleteBatch(run *models.RetentionRun, arch *archive, ids []string) error {
+	if arch != nil {
+		submissions, err := j.db.GetSubmissionsForArchive(ids)
+		if err != nil {
+			return err
+		}
+		logs, err := j.db.GetWebhookLogs(ids)
+		if err != nil {
+			return err
+		}
+
+		logsBySubmission := make(map[string][]models.WebhookLog)
+		for _, entry := range logs {
+			logsBySubmission[entry.SubmissionID] = append(logsBySubmission[entry.SubmissionID], entry)
+		}
+
+		archivedAt := time.Now()
+		records := make([]ArchiveRecord, len(submissions))
+		for i, submission := range submissions {
+			records[i] = ArchiveRecord{
+				Action:      ActionDelete,
+				ArchivedAt:  archivedAt,
+				Submission:  submission,
+				WebhookLogs: logsBySubmission[submission.ID],
+			}
+		}
+		if err := j.archive(run, arch, records); err != nil {
+			return err
+		}
+	}
+
+	submissions, webhookLogs, err := j.db.DeleteSubmissions(ids)
+	if err != nil {
+		return err
+	}
+	run.SubmissionsDeleted += submissions
+	run.WebhookLogsDeleted += webhookLogs
+	return nil
+}
+
+// purgeBatch archiva (si corresponde) y purga el payload de un lote de submissions
+func (j *Janitor) purgeBatch(run *models.RetentionRun, arch *archive, ids []string, now time.Time) error {
+	if arch != nil {
+		submissions, err := j.db.GetSubmissionsForArchive(ids)
+		if err != nil {
+			return err
+		}
+
+		archivedAt := time.Now()
+		records := make([]ArchiveRecord, len(submissions))
+		for i, submission := range submissions {
+			records[i] = ArchiveRecord{Action: ActionPurgePayload, ArchivedAt: archivedAt, Submission: submission}
+		}
+		if err := j.archive(run, arch, records); err != nil {
+			return err
+		}
+	}
+
+	purged, err := j.db.PurgeSubmissionPayloads(ids, now)
+	if err != nil {
+		return err
+	}
+	run.PayloadsPurged += purged
+	return nil
+}
+
+// archive escribe los registros antes de tocar la base: si el archivo falla,
+// la ejecución se corta sin borrar nada de ese lote
+func (j *Janitor) archive(run *models.RetentionRun, arch *archive, records []ArchiveRecord) error {
+	if err := arch.write(records); err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		run.ArchiveFile = arch.path
+	}
+	run.Archived += int64(len(records))
+	return nil
+}
+
+func (j *Janitor) batchSize() int {
+	if j.cfg.RetentionBatchSize <= 0 {
+		return 500
+	}
+	return j.cfg.RetentionBatchSize
+}
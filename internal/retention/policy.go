@@ -0,0 +1,113 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/RobertoRochaT/rojudger/internal/models"
+)
+
+// Policy define cuánto se conservan las submissions terminadas de un tenant y
+// estado. Los días en 0 significan conservar para siempre.
+type Policy struct {
+	TenantID     string `json:"tenant_id,omitempty"` // "" = cualquier tenant
+	Status       string `json:"status,omitempty"`    // "" = cualquier estado final
+	PayloadDays  int    `json:"payload_days"`        // luego se borran código, stdin y salidas
+	MetadataDays int    `json:"metadata_days"`       // luego se borra la submission completa
+}
+
+// Policies son las reglas de retención configuradas. Para cada submission gana
+// la más específica: tenant y estado, luego tenant, luego estado, luego la general.
+type Policies []Policy
+
+// LoadPolicies lee reglas adicionales de un archivo JSON (un arreglo de políticas).
+// fallback es la regla general, salvo que el archivo la redefina.
+func LoadPolicies(path string, fallback Policy) (Policies, error) {
+	fallback.TenantID, fallback.Status = "", ""
+	byScope := map[[2]string]Policy{{"", ""}: fallback}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read retention policies file: %w", err)
+		}
+
+		var list []Policy
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse retention policies file: %w", err)
+		}
+
+		for _, policy := range list {
+			if policy.PayloadDays < 0 || policy.MetadataDays < 0 {
+				return nil, fmt.Errorf("retention policy %s/%s: days must not be negative", policy.TenantID, policy.Status)
+			}
+			if policy.Status != "" && !isFinishedStatus(policy.Status) {
+				return nil, fmt.Errorf("retention policy %s/%s: status must be one of %v", policy.TenantID, policy.Status, models.FinishedStatuses)
+			}
+			byScope[[2]string{policy.TenantID, policy.Status}] = policy
+		}
+	}
+
+	policies := make(Policies, 0, len(byScope))
+	for _, policy := range byScope {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].TenantID != policies[j].TenantID {
+			return policies[i].TenantID < policies[j].TenantID
+		}
+		return policies[i].Status < policies[j].Status
+	})
+	return policies, nil
+}
+
+// Resolve retorna la regla que aplica a las submissions de un tenant ("" = sin
+// tenant propio) en un estado final
+func (p Policies) Resolve(tenantID, status string) Policy {
+	var best Policy
+	bestRank := -1
+	for _, policy := range p {
+		if policy.TenantID != "" && policy.TenantID != tenantID {
+			continue
+		}
+		if policy.Status != "" && policy.Status != status {
+			continue
+		}
+
+		rank := 0
+		if policy.TenantID != "" {
+			rank += 2
+		}
+		if policy.Status != "" {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = policy, rank
+		}
+	}
+	return best
+}
+
+// tenants retorna los tenants que tienen alguna regla propia
+func (p Policies) tenants() []string {
+	var tenants []string
+	seen := make(map[string]bool)
+	for _, policy := range p {
+		if policy.TenantID != "" && !seen[policy.TenantID] {
+			seen[policy.TenantID] = true
+			tenants = append(tenants, policy.TenantID)
+		}
+	}
+	return tenants
+}
+
+// isFinishedStatus indica si status es un estado final de submission
+func isFinishedStatus(status string) bool {
+	for _, finished := range models.FinishedStatuses {
+		if status == finished {
+			return true
+		}
+	}
+	return false
+}
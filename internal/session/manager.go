@@ -0,0 +1,315 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrLimitReached indica que se alcanzó el máximo de sesiones del servidor
+	ErrLimitReached = errors.New("maximum number of sessions reached")
+	// ErrUserLimitReached indica que el usuario ya tiene el máximo de sesiones abiertas
+	ErrUserLimitReached = errors.New("maximum number of sessions per user reached")
+)
+
+// Motivos de cierre de una sesión
+const (
+	ReasonExited      = "exited"       // el proceso de la terminal terminó
+	ReasonClosed      = "closed"       // el cliente se desconectó
+	ReasonMaxDuration = "max_duration" // se alcanzó la duración máxima
+	ReasonIdleTimeout = "idle_timeout" // sin entrada ni salida durante IdleTimeout
+	ReasonTerminated  = "terminated"   // cerrada por un administrador
+	ReasonShutdown    = "shutdown"     // el servidor se está apagando
+)
+
+// Config define los límites de las sesiones interactivas
+type Config struct {
+	MaxDuration time.Duration
+	IdleTimeout time.Duration
+	MaxPerUser  int
+	MaxTotal    int
+}
+
+// Info describe una sesión activa
+type Info struct {
+	ID           string    `json:"id"`
+	Owner        string    `json:"owner"`
+	Label        string    `json:"label,omitempty"` // etiqueta libre del cliente (X-User-ID)
+	LanguageID   int       `json:"language_id"`
+	ContainerID  string    `json:"container_id,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	LastActivity time.Time `json:"last_activity"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Session es una sesión interactiva administrada por el Manager
+type Session struct {
+	mu       sync.Mutex
+	info     Info
+	terminal *executor.Session
+
+	done   chan struct{}
+	once   sync.Once
+	reason string
+}
+
+// ID retorna el identificador de la sesión
+func (s *Session) ID() string {
+	return s.info.ID
+}
+
+// Info retorna una copia de la información de la sesión
+func (s *Session) Info() Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info
+}
+
+// Read lee la salida de la terminal
+func (s *Session) Read(p []byte) (int, error) {
+	n, err := s.terminal.Read(p)
+	if n > 0 {
+		s.touch()
+	}
+	return n, err
+}
+
+// Write envía entrada a la terminal
+func (s *Session) Write(p []byte) (int, error) {
+	s.touch()
+	return s.terminal.Write(p)
+}
+
+// Resize ajusta el tamaño de la terminal
+func (s *Session) Resize(ctx context.Context, rows, cols uint) error {
+	return s.terminal.Resize(ctx, rows, cols)
+}
+
+// Done se cierra cuando la sesión termina
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Reason retorna el motivo de cierre (vacío mientras está activa)
+func (s *Session) Reason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
+
+func (s *Session) touch() {
+	s.mu.Lock()
+	s.info.LastActivity = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *Session) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info.LastActivity
+}
+
+// Manager crea sesiones y aplica sus límites de duración, inactividad y cantidad
+type Manager struct {
+	exec   *executor.Executor
+	config Config
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	wg       sync.WaitGroup
+}
+
+// NewManager crea un nuevo administrador de sesiones
+func NewManager(exec *executor.Executor, cfg Config) *Manager {
+	return &Manager{
+		exec:     exec,
+		config:   cfg,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Open inicia una sesión para owner si no supera los límites. label solo se
+// muestra en el listado; los límites se aplican por owner.
+func (m *Manager) Open(ctx context.Context, owner, label string, language *models.Language) (*Session, error) {
+	now := time.Now()
+	s := &Session{
+		info: Info{
+			ID:           uuid.New().String(),
+			Owner:        owner,
+			Label:        label,
+			LanguageID:   language.ID,
+			StartedAt:    now,
+			LastActivity: now,
+			ExpiresAt:    now.Add(m.config.MaxDuration),
+		},
+		done: make(chan struct{}),
+	}
+
+	// Reservar el lugar antes de crear el contenedor para que los límites
+	// se respeten con aperturas concurrentes
+	m.mu.Lock()
+	if m.config.MaxTotal > 0 && len(m.sessions) >= m.config.MaxTotal {
+		m.mu.Unlock()
+		return nil, ErrLimitReached
+	}
+	if m.config.MaxPerUser > 0 && m.countOwner(owner) >= m.config.MaxPerUser {
+		m.mu.Unlock()
+		return nil, ErrUserLimitReached
+	}
+	m.sessions[s.info.ID] = s
+	m.mu.Unlock()
+
+	terminal, err := m.exec.StartSession(ctx, s.info.ID, language)
+	if err != nil {
+		m.remove(s.info.ID)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.reason != "" {
+		// Se cerró (p.ej. por un administrador) mientras arrancaba
+		s.mu.Unlock()
+		terminal.Close()
+		return nil, fmt.Errorf("session closed while starting")
+	}
+	s.terminal = terminal
+	s.info.ContainerID = terminal.ContainerID
+	s.mu.Unlock()
+
+	m.wg.Add(1)
+	go m.watch(s)
+
+	log.Printf("Session %s opened (owner: %s, language: %d)", s.info.ID, owner, language.ID)
+	return s, nil
+}
+
+// Get retorna una sesión activa
+func (m *Manager) Get(id string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	return s, ok
+}
+
+// List retorna las sesiones activas ordenadas por inicio
+func (m *Manager) List() []Info {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.Unlock()
+
+	infos := make([]Info, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, s.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// Close termina una sesión; retorna false si no existe
+func (m *Manager) Close(id, reason string) bool {
+	s, ok := m.Get(id)
+	if !ok {
+		return false
+	}
+	m.close(s, reason)
+	return true
+}
+
+// CloseAll termina todas las sesiones y espera a que se eliminen sus contenedores
+func (m *Manager) CloseAll(reason string) {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		m.close(s, reason)
+	}
+	m.wg.Wait()
+}
+
+// watch cierra la sesión al superar la duración máxima o el tiempo de inactividad
+func (m *Manager) watch(s *Session) {
+	defer m.wg.Done()
+
+	deadline := time.NewTimer(time.Until(s.info.ExpiresAt))
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-deadline.C:
+			m.close(s, ReasonMaxDuration)
+		case <-ticker.C:
+			if m.config.IdleTimeout > 0 && time.Since(s.idleSince()) > m.config.IdleTimeout {
+				m.close(s, ReasonIdleTimeout)
+			}
+		}
+	}
+}
+
+// close cierra la sesión una sola vez y libera su lugar
+func (m *Manager) close(s *Session, reason string) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.reason = reason
+		terminal := s.terminal
+		s.mu.Unlock()
+
+		close(s.done)
+		if terminal != nil {
+			terminal.Close()
+		}
+		m.remove(s.info.ID)
+
+		log.Printf("Session %s closed (%s)", s.info.ID, reason)
+	})
+}
+
+func (m *Manager) remove(id string) {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+}
+
+// countOwner cuenta las sesiones de un usuario (requiere m.mu)
+func (m *Manager) countOwner(owner string) int {
+	count := 0
+	for _, s := range m.sessions {
+		if s.info.Owner == owner {
+			count++
+		}
+	}
+	return count
+}
+
+// Cleanup elimina contenedores de sesiones huérfanas de ejecuciones anteriores
+func (m *Manager) Cleanup(ctx context.Context) error {
+	removed, err := m.exec.CleanupSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup sessions: %w", err)
+	}
+	if removed > 0 {
+		log.Printf("Removed %d orphaned session containers", removed)
+	}
+	return nil
+}
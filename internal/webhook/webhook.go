@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/RobertoRochaT/rojudger/internal/models"
@@ -24,6 +25,7 @@ type WebhookService struct {
 	timeout    time.Duration
 	retries    int
 	hmacSecret string
+	pending    sync.WaitGroup // envíos asíncronos en curso
 }
 
 // NewWebhookService crea un nuevo servicio de webhooks
@@ -54,6 +56,11 @@ type WebhookResult struct {
 	Attempt      int
 }
 
+// DefaultSecret retorna el secreto global (WEBHOOK_SECRET) con el que se firman los webhooks
+func (ws *WebhookService) DefaultSecret() string {
+	return ws.hmacSecret
+}
+
 // ValidateWebhookURL valida que la URL del webhook sea segura
 func ValidateWebhookURL(webhookURL string) error {
 	if webhookURL == "" {
@@ -87,18 +94,23 @@ func ValidateWebhookURL(webhookURL string) error {
 }
 
 // generateHMAC genera una firma HMAC-SHA256 del payload
-func (ws *WebhookService) generateHMAC(payload []byte) string {
-	if ws.hmacSecret == "" {
+func generateHMAC(payload []byte, secret string) string {
+	if secret == "" {
 		return ""
 	}
 
-	h := hmac.New(sha256.New, []byte(ws.hmacSecret))
+	h := hmac.New(sha256.New, []byte(secret))
 	h.Write(payload)
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// Send envía un webhook con reintentos
+// Send envía un webhook con reintentos, firmado con el secreto global
 func (ws *WebhookService) Send(ctx context.Context, webhookURL string, submission *models.Submission) *WebhookResult {
+	return ws.SendWithSecret(ctx, webhookURL, submission, ws.hmacSecret)
+}
+
+// SendWithSecret envía un webhook firmado con secret (p.ej. el del tenant)
+func (ws *WebhookService) SendWithSecret(ctx context.Context, webhookURL string, submission *models.Submission, secret string) *WebhookResult {
 	result := &WebhookResult{}
 
 	if webhookURL == "" {
@@ -156,8 +168,8 @@ func (ws *WebhookService) Send(ctx context.Context, webhookURL string, submissio
 		req.Header.Set("X-Rojudger-Delivery", fmt.Sprintf("%d", time.Now().Unix()))
 
 		// HMAC signature
-		if ws.hmacSecret != "" {
-			signature := ws.generateHMAC(jsonData)
+		if secret != "" {
+			signature := generateHMAC(jsonData, secret)
 			req.Header.Set("X-Rojudger-Signature", signature)
 		}
 
@@ -193,11 +205,19 @@ func (ws *WebhookService) Send(ctx context.Context, webhookURL string, submissio
 
 // SendAsync envía un webhook de forma asíncrona
 func (ws *WebhookService) SendAsync(webhookURL string, submission *models.Submission, logger func(submissionID, webhookURL string, attempt, statusCode int, responseBody, errorMsg string)) {
+	ws.SendAsyncWithSecret(webhookURL, submission, ws.hmacSecret, logger)
+}
+
+// SendAsyncWithSecret es SendAsync firmando con secret
+func (ws *WebhookService) SendAsyncWithSecret(webhookURL string, submission *models.Submission, secret string, logger func(submissionID, webhookURL string, attempt, statusCode int, responseBody, errorMsg string)) {
+	ws.pending.Add(1)
 	go func() {
+		defer ws.pending.Done()
+
 		ctx, cancel := context.WithTimeout(context.Background(), ws.timeout)
 		defer cancel()
 
-		result := ws.Send(ctx, webhookURL, submission)
+		result := ws.SendWithSecret(ctx, webhookURL, submission, secret)
 
 		// Log del resultado
 		if logger != nil {
@@ -213,3 +233,20 @@ func (ws *WebhookService) SendAsync(webhookURL string, submission *models.Submis
 		}
 	}()
 }
+
+// Wait espera a que terminen los envíos asíncronos pendientes.
+// Retorna false si se alcanzó el timeout antes de terminar.
+func (ws *WebhookService) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		ws.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
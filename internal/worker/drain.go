@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// errJobAborted indica que un trabajo se interrumpió por el apagado del worker
+// y debe reencolarse en lugar de guardarse como resultado
+var errJobAborted = errors.New("job aborted by worker shutdown")
+
+// drainer coordina el modo drain: dejar de tomar trabajos y terminar los que
+// están en curso antes de apagarse
+type drainer struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// newDrainer crea un drainer inactivo
+func newDrainer() *drainer {
+	return &drainer{ch: make(chan struct{})}
+}
+
+// start activa el modo drain (solo la primera llamada tiene efecto)
+func (d *drainer) start(reason string) {
+	d.once.Do(func() {
+		log.Printf("⚠️  Draining worker (%s): no new jobs will be taken", reason)
+		close(d.ch)
+	})
+}
+
+// done se cierra cuando el modo drain está activo
+func (d *drainer) done() <-chan struct{} {
+	return d.ch
+}
+
+// active indica si el modo drain está activo
+func (d *drainer) active() bool {
+	select {
+	case <-d.ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,232 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/RobertoRochaT/rojudger/internal/queue"
+	"github.com/RobertoRochaT/rojudger/internal/ratelimit"
+	"github.com/RobertoRochaT/rojudger/internal/webhook"
+)
+
+// Pool es un proceso worker: varios ejecutores concurrentes que consumen la
+// cola, el heartbeat que lo registra y el scheduler de submissions programadas.
+// Lo usan cmd/worker y el modo all-in-one del API.
+type Pool struct {
+	cfg      *config.Config
+	db       database.Store
+	queue    queue.Queue
+	exec     *executor.Executor
+	limiter  *ratelimit.Limiter
+	webhooks *webhook.WebhookService
+	size     int
+	state    *workerState
+	drain    *drainer
+
+	// ctx controla los procesos de fondo (heartbeat, scheduler) y se cancela al final.
+	// jobCtx controla las ejecuciones y solo se cancela si vence el plazo de drain.
+	ctx       context.Context
+	cancel    context.CancelFunc
+	jobCtx    context.Context
+	abortJobs context.CancelFunc
+
+	// WaitGroups para procesos de fondo y para los workers
+	wg, workersWG sync.WaitGroup
+}
+
+// NewPool prepara un pool de EXECUTOR_MAX_CONCURRENT ejecutores. Falla si el
+// worker no tiene nada que consumir (sin lenguajes y sin pool general).
+func NewPool(cfg *config.Config, db database.Store, q queue.Queue, exec *executor.Executor, limiter *ratelimit.Limiter) (*Pool, error) {
+	// Número de workers concurrentes
+	size := cfg.ExecutorMaxConcurrent
+	if size == 0 {
+		size = 5
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobCtx, abortJobs := context.WithCancel(context.Background())
+
+	state := newWorkerState(cfg.WorkerID, size, detectLanguages(ctx, cfg, db, exec), cfg.WorkerGeneralPool)
+	if len(state.info.Languages) == 0 && !state.info.GeneralPool {
+		cancel()
+		abortJobs()
+		return nil, fmt.Errorf("worker has no languages and WORKER_GENERAL_POOL=false: nothing to consume")
+	}
+
+	// Crear webhook service
+	hmacSecret := os.Getenv("WEBHOOK_SECRET")
+	if hmacSecret == "" {
+		log.Println("⚠️  WEBHOOK_SECRET not set. Webhooks of tenants without their own secret will be sent without HMAC signatures.")
+	}
+	webhookService := webhook.NewWebhookService(30*time.Second, 3, hmacSecret)
+
+	return &Pool{
+		cfg:       cfg,
+		db:        db,
+		queue:     q,
+		exec:      exec,
+		limiter:   limiter,
+		webhooks:  webhookService,
+		size:      size,
+		state:     state,
+		drain:     newDrainer(),
+		ctx:       ctx,
+		cancel:    cancel,
+		jobCtx:    jobCtx,
+		abortJobs: abortJobs,
+	}, nil
+}
+
+// Start lanza el heartbeat, los ejecutores y el scheduler
+func (p *Pool) Start() {
+	log.Printf("Worker ID: %s (languages: %v, general pool: %v)", p.state.info.ID, p.state.info.Languages, p.state.info.GeneralPool)
+	log.Printf("Starting %d concurrent workers", p.size)
+
+	// Registro del worker en la cola (heartbeats)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		runHeartbeat(p.ctx, p.queue, p.state, p.drain, p.cfg.WorkerHeartbeatInterval)
+	}()
+
+	// Iniciar workers
+	for i := 0; i < p.size; i++ {
+		p.workersWG.Add(1)
+		go func(workerID int) {
+			defer p.workersWG.Done()
+			p.runWorker(workerID)
+		}(i + 1)
+	}
+
+	// Scheduler: mueve submissions programadas a la cola cuando llega su hora
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.queue.RunScheduler(p.ctx, time.Second, func(job queue.Job) error {
+			if err := p.db.UpdateSubmissionStatus(job.SubmissionID, models.StatusQueued); err != nil {
+				return err
+			}
+			publishStatus(p.ctx, p.queue, job.SubmissionID, models.StatusQueued)
+			return nil
+		})
+	}()
+}
+
+// Draining se cierra cuando el pool entra en modo drain (p.ej. a pedido de un admin)
+func (p *Pool) Draining() <-chan struct{} {
+	return p.drain.done()
+}
+
+// Shutdown deja de tomar trabajos y espera a los que están en curso hasta
+// WORKER_DRAIN_TIMEOUT. Si vence el plazo o llega una señal por force, los
+// interrumpe y los reencola. Después entrega los webhooks pendientes y
+// desregistra el worker.
+func (p *Pool) Shutdown(reason string, force <-chan os.Signal) {
+	p.drain.start(reason)
+	p.state.setDraining()
+
+	// Esperar a que terminen los trabajos en curso hasta el plazo de drain.
+	// Una segunda señal fuerza el apagado inmediato.
+	workersDone := make(chan struct{})
+	go func() {
+		p.workersWG.Wait()
+		close(workersDone)
+	}()
+
+	select {
+	case <-workersDone:
+		log.Println("✅ In-flight jobs finished")
+	case <-time.After(p.cfg.WorkerDrainTimeout):
+		log.Printf("⚠️  Drain timeout (%v) reached. Requeueing unfinished jobs...", p.cfg.WorkerDrainTimeout)
+		p.abortJobs()
+		<-workersDone
+	case <-force:
+		log.Println("⚠️  Second signal received. Requeueing unfinished jobs...")
+		p.abortJobs()
+		<-workersDone
+	}
+
+	// Entregar webhooks pendientes antes de salir
+	if !p.webhooks.Wait(p.cfg.WorkerDrainTimeout) {
+		log.Println("⚠️  Some webhook deliveries did not finish before shutdown")
+	}
+
+	// Detener heartbeat (se desregistra) y scheduler
+	p.cancel()
+	p.wg.Wait()
+	p.abortJobs()
+}
+
+// runWorker toma trabajos hasta que se active el modo drain. Los trabajos se
+// ejecutan con jobCtx, que solo se cancela si vence el plazo de drain.
+func (p *Pool) runWorker(workerID int) {
+	log.Printf("Worker #%d started", workerID)
+	ctx := p.jobCtx
+
+	for {
+		select {
+		case <-p.drain.done():
+			log.Printf("Worker #%d stopping...", workerID)
+			return
+		default:
+			// Intentar obtener un trabajo (esperar hasta 5 segundos)
+			// Solo colas de lenguajes soportados (+ pool general si está habilitado)
+			job, err := p.queue.Dequeue(ctx, 5*time.Second, p.state.info.Languages, p.state.info.GeneralPool)
+			if err != nil {
+				log.Printf("Worker #%d: Error dequeuing job: %v", workerID, err)
+				time.Sleep(1 * time.Second)
+				continue
+			}
+
+			// Si no hay trabajos, continuar esperando
+			if job == nil {
+				continue
+			}
+
+			// Procesar el trabajo
+			log.Printf("Worker #%d: Processing job %s", workerID, job.SubmissionID)
+
+			p.state.start(job.SubmissionID)
+			err = p.processSubmission(ctx, workerID, *job)
+			p.state.finish(job.SubmissionID)
+
+			if errors.Is(err, errJobAborted) {
+				p.requeueJob(workerID, *job)
+			} else if err != nil {
+				log.Printf("Worker #%d: Error processing job %s: %v", workerID, job.SubmissionID, err)
+				p.queue.MarkFailed(ctx, *job, false)
+			} else {
+				log.Printf("Worker #%d: Job %s completed successfully", workerID, job.SubmissionID)
+				p.queue.MarkComplete(ctx, job.SubmissionID)
+			}
+		}
+	}
+}
+
+// requeueJob devuelve a la cola un trabajo interrumpido por el apagado
+func (p *Pool) requeueJob(workerID int, job queue.Job) {
+	// El contexto de trabajos ya está cancelado: usar uno nuevo
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.db.UpdateSubmissionStatus(job.SubmissionID, models.StatusQueued); err != nil {
+		log.Printf("Worker #%d: Failed to reset submission %s: %v", workerID, job.SubmissionID, err)
+	}
+	// La salida parcial del intento interrumpido ya no aplica
+	p.queue.ClearOutput(ctx, job.SubmissionID)
+	if err := p.queue.Requeue(ctx, job); err != nil {
+		log.Printf("Worker #%d: Failed to requeue job %s: %v", workerID, job.SubmissionID, err)
+		return
+	}
+	publishStatus(ctx, p.queue, job.SubmissionID, models.StatusQueued)
+	log.Printf("Worker #%d: Job %s requeued", workerID, job.SubmissionID)
+}
@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/models"
+	"github.com/RobertoRochaT/rojudger/internal/queue"
+)
+
+// processSubmission ejecuta una submission y guarda su resultado, su consumo y
+// notifica a los suscriptores y al webhook
+func (p *Pool) processSubmission(ctx context.Context, workerID int, job queue.Job) error {
+	db, q := p.db, p.queue
+	submissionID := job.SubmissionID
+
+	// 1. Obtener submission de la base de datos
+	submission, err := db.GetSubmission(submissionID)
+	if err != nil {
+		return err
+	}
+
+	// Submissions canceladas no se ejecutan
+	if submission.Status == models.StatusCancelled {
+		log.Printf("Worker #%d: Skipping cancelled submission %s", workerID, submissionID)
+		return nil
+	}
+
+	// 2. Actualizar estado a "processing"
+	submission.Status = "processing"
+	if err := db.UpdateSubmission(submission); err != nil {
+		return err
+	}
+	publishStatus(ctx, q, submission.ID, submission.Status)
+
+	// 3. Obtener información del lenguaje
+	language, err := db.GetLanguage(submission.LanguageID)
+	if err != nil {
+		return err
+	}
+
+	// Los tenants tienen sus propios techos de recursos y secreto de webhooks
+	execCtx := ctx
+	webhookSecret := p.webhooks.DefaultSecret()
+	if submission.TenantID != "" {
+		tenant, err := db.GetTenant(submission.TenantID)
+		if err != nil {
+			return err
+		}
+		execCtx = executor.WithLimits(ctx, executor.TenantLimits(tenant))
+		if tenant.WebhookSecret != "" {
+			webhookSecret = tenant.WebhookSecret
+		}
+	}
+
+	// 4. Ejecutar el código
+	log.Printf("Worker #%d: Executing code for submission %s (language: %s)",
+		workerID, submissionID, language.DisplayName)
+
+	result := p.exec.ExecuteStreaming(execCtx, submission, language, outputPublisher(ctx, q, submission.ID))
+
+	// Si el worker se apagó durante la ejecución el resultado no es válido
+	if ctx.Err() != nil {
+		return errJobAborted
+	}
+
+	// 5. Actualizar submission con los resultados
+	now := time.Now().UTC()
+	submission.FinishedAt = &now
+	submission.Stdout = result.Stdout
+	submission.Stderr = result.Stderr
+	submission.ExitCode = result.ExitCode
+	submission.Time = result.Time
+	submission.Memory = result.Memory
+	submission.CompileOut = result.CompileOut
+
+	if result.TimedOut {
+		submission.Status = "timeout"
+		submission.Message = "Execution timed out"
+	} else if result.Error != "" {
+		submission.Status = "error"
+		submission.Message = result.Error
+	} else {
+		submission.Status = "completed"
+	}
+
+	// 6. Guardar en base de datos
+	if err := db.UpdateSubmission(submission); err != nil {
+		return err
+	}
+
+	// Registrar el consumo para facturación
+	if err := db.RecordUsage(models.NewUsageRecord(submission, language, result, now)); err != nil {
+		log.Printf("Worker #%d: Failed to record metered usage for %s: %v", workerID, submissionID, err)
+	}
+
+	// Cargar la CPU consumida a la cuota del cliente
+	if p.limiter != nil {
+		if err := p.limiter.RecordCPU(ctx, job.QuotaKey, submission.Time, now); err != nil {
+			log.Printf("Worker #%d: Failed to record usage for %s: %v", workerID, submissionID, err)
+		}
+	}
+
+	// Notificar a quien espere el resultado (wait=true)
+	if err := q.Publish(ctx, queue.Event{
+		Type:         queue.EventResult,
+		SubmissionID: submission.ID,
+		Status:       submission.Status,
+	}); err != nil {
+		log.Printf("Worker #%d: Failed to publish result for %s: %v", workerID, submissionID, err)
+	}
+
+	// 7. Enviar webhook si está configurado
+	if submission.WebhookURL != "" {
+		log.Printf("Worker #%d: Sending webhook for submission %s to %s",
+			workerID, submissionID, submission.WebhookURL)
+
+		// Enviar de forma asíncrona con logging
+		p.webhooks.SendAsyncWithSecret(submission.WebhookURL, submission, webhookSecret, func(submissionID, webhookURL string, attempt, statusCode int, responseBody, errorMsg string) {
+			// Log en base de datos
+			if err := db.LogWebhookAttempt(submissionID, webhookURL, attempt, statusCode, responseBody, errorMsg); err != nil {
+				log.Printf("Worker #%d: Failed to log webhook attempt: %v", workerID, err)
+			}
+		})
+	}
+
+	return nil
+}
+
+// publishStatus notifica un cambio de estado a los clientes suscritos (SSE/WebSocket)
+func publishStatus(ctx context.Context, q queue.Queue, submissionID, status string) {
+	err := q.Publish(ctx, queue.Event{
+		Type:         queue.EventStatus,
+		SubmissionID: submissionID,
+		Status:       status,
+	})
+	if err != nil {
+		log.Printf("Failed to publish status for %s: %v", submissionID, err)
+	}
+}
+
+// maxStreamedOutput limita cuántos bytes de salida se publican en vivo por submission.
+// La salida completa se guarda igual en la base de datos.
+const maxStreamedOutput = 1 << 20
+
+// outputPublisher retorna un callback que publica la salida en vivo (SSE/WebSocket)
+func outputPublisher(ctx context.Context, q queue.Queue, submissionID string) executor.OutputFunc {
+	var seq int64
+	var published int
+
+	return func(stream string, data []byte) {
+		if published >= maxStreamedOutput {
+			return
+		}
+		if remaining := maxStreamedOutput - published; len(data) > remaining {
+			data = data[:remaining]
+		}
+		published += len(data)
+		seq++
+
+		if err := q.PublishOutput(ctx, submissionID, seq, stream, data); err != nil {
+			log.Printf("Failed to publish output for %s: %v", submissionID, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RobertoRochaT/rojudger/internal/config"
+	"github.com/RobertoRochaT/rojudger/internal/database"
+	"github.com/RobertoRochaT/rojudger/internal/executor"
+	"github.com/RobertoRochaT/rojudger/internal/queue"
+)
+
+// Version se puede sobrescribir al compilar con
+// -ldflags "-X github.com/RobertoRochaT/rojudger/internal/worker.Version=..."
+var Version = "1.0.0"
+
+// workerState lleva el registro de lo que está ejecutando este proceso
+type workerState struct {
+	mu      sync.Mutex
+	info    queue.WorkerInfo
+	running map[string]struct{}
+}
+
+// newWorkerState crea el estado inicial del proceso worker
+func newWorkerState(id string, capacity int, languages []int, generalPool bool) *workerState {
+	hostname, _ := os.Hostname()
+	if id == "" {
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	return &workerState{
+		info: queue.WorkerInfo{
+			ID:          id,
+			Hostname:    hostname,
+			Version:     Version,
+			Capacity:    capacity,
+			Languages:   languages,
+			GeneralPool: generalPool,
+			StartedAt:   time.Now(),
+		},
+		running: make(map[string]struct{}),
+	}
+}
+
+// start marca una submission como en ejecución
+func (s *workerState) start(submissionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[submissionID] = struct{}{}
+}
+
+// finish quita una submission de las que están en ejecución
+func (s *workerState) finish(submissionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, submissionID)
+}
+
+// setDraining marca el worker como drenando en el registro
+func (s *workerState) setDraining() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info.Draining = true
+}
+
+// snapshot retorna una copia de la información para publicar
+func (s *workerState) snapshot() queue.WorkerInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := s.info
+	info.Running = make([]string, 0, len(s.running))
+	for id := range s.running {
+		info.Running = append(info.Running, id)
+	}
+	sort.Strings(info.Running)
+	return info
+}
+
+// runHeartbeat publica el estado del worker periódicamente hasta que se cancele ctx.
+// También revisa si un admin pidió drenar el worker.
+func runHeartbeat(ctx context.Context, q queue.Queue, state *workerState, drain *drainer, interval time.Duration) {
+	// El registro expira si se pierden tres heartbeats seguidos
+	ttl := 3 * interval
+
+	beat := func() {
+		if !drain.active() && q.DrainRequested(ctx, state.info.ID) {
+			drain.start("admin request")
+		}
+		if err := q.Heartbeat(ctx, state.snapshot(), ttl); err != nil {
+			log.Printf("Heartbeat failed: %v", err)
+		}
+	}
+
+	beat()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			unregisterCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := q.UnregisterWorker(unregisterCtx, state.snapshot()); err != nil {
+				log.Printf("Failed to unregister worker: %v", err)
+			}
+			return
+		case <-ticker.C:
+			beat()
+		}
+	}
+}
+
+// detectLanguages retorna los lenguajes que este worker puede ejecutar: los
+// configurados en WORKER_LANGUAGES o, si no hay, los habilitados cuya imagen
+// Docker está disponible localmente
+func detectLanguages(ctx context.Context, cfg *config.Config, db database.Store, exec *executor.Executor) []int {
+	if len(cfg.WorkerLanguages) > 0 {
+		return cfg.WorkerLanguages
+	}
+
+	languages, err := db.GetAllLanguages()
+	if err != nil {
+		log.Printf("Failed to load languages: %v", err)
+		return nil
+	}
+
+	available := make([]int, 0, len(languages))
+	for _, lang := range languages {
+		if exec.ImageAvailable(ctx, lang.DockerImage) {
+			available = append(available, lang.ID)
+		} else {
+			log.Printf("⚠️  Image %s not found locally (language %s)", lang.DockerImage, lang.Name)
+		}
+	}
+	return available
+}